@@ -0,0 +1,124 @@
+package ecs
+
+import (
+	"fmt"
+	"slices"
+)
+
+// access is the declared data access of a system.
+type access struct {
+	declared        bool
+	reads           Signature
+	writes          Signature
+	singletonReads  []singletonAccess
+	singletonWrites []singletonAccess
+}
+
+type singletonAccess struct {
+	id   uint64
+	name string
+}
+
+type accessOption struct {
+	sig       Signature
+	singleton singletonAccess
+	write     bool
+}
+
+func (o accessOption) applySystem(se *systemEntry) {
+	a := &se.access
+	a.declared = true
+	switch {
+	case o.singleton.id != 0 && o.write:
+		a.singletonWrites = append(a.singletonWrites, o.singleton)
+	case o.singleton.id != 0:
+		a.singletonReads = append(a.singletonReads, o.singleton)
+	case o.write:
+		a.writes = a.writes.Or(o.sig)
+	default:
+		a.reads = a.reads.Or(o.sig)
+	}
+}
+
+// Reads declares that the system reads the components in sig.
+//
+// Systems that declare their access can be run in parallel with each other,
+// see World.SetParallel. Systems without declarations always run alone.
+func Reads(sig Signature) SystemOption {
+	return accessOption{sig: sig}
+}
+
+// Writes declares that the system modifies the components in sig, or adds or
+// removes them from entities.
+func Writes(sig Signature) SystemOption {
+	return accessOption{sig: sig, write: true}
+}
+
+// ReadsSingleton declares that the system reads singleton of type T.
+func ReadsSingleton[T any]() SystemOption {
+	var t T
+	return accessOption{singleton: singletonAccess{id: TypeID(t), name: fmt.Sprintf("%T", t)}}
+}
+
+// WritesSingleton declares that the system modifies singleton of type T.
+func WritesSingleton[T any]() SystemOption {
+	var t T
+	return accessOption{singleton: singletonAccess{id: TypeID(t), name: fmt.Sprintf("%T", t)}, write: true}
+}
+
+// conflict describes why systems a and b can not run at the same time, or
+// returns empty string if they can.
+func conflict(cm *componentManager, a, b *systemEntry) string {
+	if !a.access.declared {
+		return fmt.Sprintf("%s does not declare its access", systemName(a))
+	}
+	if !b.access.declared {
+		return fmt.Sprintf("%s does not declare its access", systemName(b))
+	}
+	if r := componentConflict(cm, a, b); r != "" {
+		return r
+	}
+	if r := componentConflict(cm, b, a); r != "" {
+		return r
+	}
+	if r := singletonConflict(a, b); r != "" {
+		return r
+	}
+	return singletonConflict(b, a)
+}
+
+func componentConflict(cm *componentManager, writer, other *systemEntry) string {
+	for _, idx := range writer.access.writes.indices() {
+		verb := ""
+		switch {
+		case other.access.writes.has(idx):
+			verb = "writes"
+		case other.access.reads.has(idx):
+			verb = "reads"
+		default:
+			continue
+		}
+		return fmt.Sprintf("%s writes %s which %s %s", systemName(writer), cm.components[idx].name, systemName(other), verb)
+	}
+	return ""
+}
+
+func singletonConflict(writer, other *systemEntry) string {
+	for _, s := range writer.access.singletonWrites {
+		verb := ""
+		switch {
+		case slices.ContainsFunc(other.access.singletonWrites, s.same):
+			verb = "writes"
+		case slices.ContainsFunc(other.access.singletonReads, s.same):
+			verb = "reads"
+		default:
+			continue
+		}
+		return fmt.Sprintf("%s writes singleton %s which %s %s", systemName(writer), s.name, systemName(other), verb)
+	}
+	return ""
+}
+
+func (s singletonAccess) same(o singletonAccess) bool {
+	return s.id == o.id
+}
@@ -0,0 +1,197 @@
+package ecs
+
+import "fmt"
+
+// chunkCapacity is the number of entities stored in a single archetype chunk.
+const chunkCapacity = 128
+
+// tagColumn and sparseColumn are the column indices of tags and components stored in
+// sparse sets, which are in the signature of the archetype but have no column.
+const (
+	tagColumn    = -2
+	sparseColumn = -3
+)
+
+// archetype stores all entities that share the same Signature.
+//
+// Components are stored column wise in fixed size chunks, so walking the entities
+// of an archetype touches contiguous memory and pointers to components stay valid
+// until the entity is moved by a structural change.
+type archetype struct {
+	sig        Signature
+	components []int
+	tags       []int
+	sparse     []int
+	columns    []int
+	factories  []func() columnType
+	chunks     []*chunk
+	len        int
+
+	addEdges    map[int]*archetype
+	removeEdges map[int]*archetype
+}
+
+type chunk struct {
+	entities []EntityID
+	columns  []columnType
+}
+
+type columnType interface {
+	copyRow(dst columnType, dstRow, srcRow int)
+	// copyInto copies the first n rows to dst and returns it. New column is
+	// allocated if dst is nil.
+	copyInto(dst columnType, n int) columnType
+	get(row int) any
+	set(row int, v any, tick uint64)
+	zero(row int)
+	ticks(row int) *componentTicks
+	debug(row int) string
+}
+
+// componentTicks records the world tick when the component was added to the
+// entity and when it was last changed.
+type componentTicks struct {
+	added   uint64
+	changed uint64
+}
+
+type column[T any] struct {
+	data  []T
+	stamp []componentTicks
+}
+
+func newColumn[T any]() columnType {
+	return &column[T]{
+		data:  make([]T, chunkCapacity),
+		stamp: make([]componentTicks, chunkCapacity),
+	}
+}
+
+func (c *column[T]) copyRow(dst columnType, dstRow, srcRow int) {
+	d := dst.(*column[T])
+	d.data[dstRow] = c.data[srcRow]
+	d.stamp[dstRow] = c.stamp[srcRow]
+}
+
+func (c *column[T]) copyInto(dst columnType, n int) columnType {
+	if dst == nil {
+		dst = newColumn[T]()
+	}
+	d := dst.(*column[T])
+	copy(d.data[:n], c.data[:n])
+	copy(d.stamp[:n], c.stamp[:n])
+	return d
+}
+
+func (c *column[T]) get(row int) any {
+	return c.data[row]
+}
+
+func (c *column[T]) set(row int, v any, tick uint64) {
+	c.data[row] = v.(T)
+	c.stamp[row] = componentTicks{added: tick, changed: tick}
+}
+
+func (c *column[T]) zero(row int) {
+	var t T
+	c.data[row] = t
+	c.stamp[row] = componentTicks{}
+}
+
+func (c *column[T]) ticks(row int) *componentTicks {
+	return &c.stamp[row]
+}
+
+func (c *column[T]) debug(row int) string {
+	return fmt.Sprintf("%+v", c.data[row])
+}
+
+func newArchetype(sig Signature, components []componentInfo) *archetype {
+	a := &archetype{
+		sig:         sig,
+		columns:     make([]int, len(components)),
+		addEdges:    make(map[int]*archetype),
+		removeEdges: make(map[int]*archetype),
+	}
+	for i, c := range components {
+		a.columns[i] = -1
+		if !sig.has(i) {
+			continue
+		}
+		if c.tag {
+			a.columns[i] = tagColumn
+			a.tags = append(a.tags, i)
+			continue
+		}
+		if c.sparse {
+			a.columns[i] = sparseColumn
+			a.sparse = append(a.sparse, i)
+			continue
+		}
+		a.columns[i] = len(a.components)
+		a.components = append(a.components, i)
+		a.factories = append(a.factories, c.newColumn)
+	}
+	return a
+}
+
+// column returns the column index of the component in the archetype chunks, or a
+// negative value if the archetype does not store it.
+func (a *archetype) column(component int) int {
+	if component >= len(a.columns) {
+		return -1
+	}
+	return a.columns[component]
+}
+
+func (a *archetype) newChunk() *chunk {
+	c := &chunk{
+		entities: make([]EntityID, 0, chunkCapacity),
+		columns:  make([]columnType, len(a.factories)),
+	}
+	for i, f := range a.factories {
+		c.columns[i] = f()
+	}
+	return c
+}
+
+// alloc reserves a row for the entity and returns its chunk and row index.
+func (a *archetype) alloc(e EntityID) (int, int) {
+	if len(a.chunks) == 0 || len(a.chunks[len(a.chunks)-1].entities) == chunkCapacity {
+		a.chunks = append(a.chunks, a.newChunk())
+	}
+	ci := len(a.chunks) - 1
+	c := a.chunks[ci]
+	c.entities = append(c.entities, e)
+	a.len++
+	return ci, len(c.entities) - 1
+}
+
+// remove swaps the last entity of the archetype into the removed row to keep the
+// chunks dense. It returns the entity that now occupies the row, if any was moved.
+func (a *archetype) remove(ci, row int) (EntityID, bool) {
+	lastIdx := len(a.chunks) - 1
+	last := a.chunks[lastIdx]
+	lastRow := len(last.entities) - 1
+	c := a.chunks[ci]
+	moved := ci != lastIdx || row != lastRow
+	if moved {
+		c.entities[row] = last.entities[lastRow]
+		for i, col := range last.columns {
+			col.copyRow(c.columns[i], row, lastRow)
+		}
+	}
+	for _, col := range last.columns {
+		col.zero(lastRow)
+	}
+	last.entities = last.entities[:lastRow]
+	if lastRow == 0 && lastIdx > 0 {
+		a.chunks[lastIdx] = nil
+		a.chunks = a.chunks[:lastIdx]
+	}
+	a.len--
+	if !moved {
+		return 0, false
+	}
+	return c.entities[row], true
+}
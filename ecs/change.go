@@ -0,0 +1,64 @@
+package ecs
+
+import "fmt"
+
+// removedRetention is the number of frames that the removal records are kept for
+// the systems that have not run since.
+const removedRetention = 64
+
+type removedComponent struct {
+	entity    EntityID
+	component int
+	tick      uint64
+	frame     uint64
+	// despawned is the signature of an entity removed with RemoveEntity, in which
+	// case the record stands for all of its components.
+	despawned Signature
+}
+
+// trimRemoved drops the removal records that every system has already seen, and the
+// records older than removedRetention frames, so that systems that do not run
+// do not keep the records forever.
+func (w *World) trimRemoved() {
+	oldest := w.prevTick
+	for _, s := range w.sm.systems {
+		oldest = min(oldest, s.lastRun)
+	}
+	n := 0
+	for _, r := range w.removed {
+		if r.tick > oldest && w.frame-r.frame < removedRetention {
+			w.removed[n] = r
+			n++
+		}
+	}
+	clear(w.removed[n:])
+	w.removed = w.removed[:n]
+}
+
+// GetComponentMut returns component of type T attached to the entity and marks it
+// changed for the Changed filter.
+//
+// Pointers returned by GetComponent can be used to modify the component as well,
+// but those modifications are not seen by change detection.
+func GetComponentMut[T any](w *World, e EntityID) *T {
+	c := getComponent[T](w, e)
+	if c != nil {
+		MarkChanged[T](w, e)
+	}
+	return c
+}
+
+// MarkChanged marks component of type T on the entity as changed.
+//
+// Calling this on entity that does not have the component will panic.
+func MarkChanged[T any](w *World, e EntityID) {
+	idx := getComponentIdx[T](w.cm)
+	rec, ok := w.visible(e, idx)
+	if !ok {
+		var t T
+		panic(fmt.Sprintf("entity %v does not have component of type %T", e, t))
+	}
+	if col, row := w.cell(e, rec, idx); col != nil {
+		col.ticks(row).changed = w.tick
+	}
+}
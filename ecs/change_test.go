@@ -0,0 +1,85 @@
+package ecs
+
+import "testing"
+
+type removedSystem struct{}
+
+func (removedSystem) Update(us UpdateState) {
+	for range Query1[benchPosition](us, Removed(Sig[benchHealth](us.World))) {
+	}
+}
+
+func TestRemovedRetention(t *testing.T) {
+	w := New()
+	RegisterComponent[benchPosition](w)
+	RegisterComponent[benchHealth](w)
+	sig := Sig[benchPosition](w)
+	RegisterSystem(w, removedSystem{}, sig, RunIf(func(*World) bool { return false }))
+	disabled := RegisterSystem(w, removedSystem{}, sig)
+	SetSystemHandleEnabled(w, disabled, false)
+	e := NewEntity(w)
+	AddComponent(w, e, benchPosition{})
+	w.Init()
+	for range 10 * removedRetention {
+		AddComponent(w, e, benchHealth{})
+		w.RunUpdate(0)
+		RemoveComponent[benchHealth](w, e)
+		w.RunUpdate(0)
+	}
+	if len(w.removed) > removedRetention {
+		t.Fatalf("%d removal records kept, want at most %d", len(w.removed), removedRetention)
+	}
+}
+
+func TestRemovedDespawn(t *testing.T) {
+	w := New()
+	RegisterComponent[benchPosition](w)
+	RegisterComponent[benchHealth](w)
+	RegisterComponent[benchVelocity](w)
+	var despawned, moving []EntityID
+	for i := range 3 {
+		e := NewEntity(w)
+		AddComponent(w, e, benchPosition{})
+		AddComponent(w, e, benchHealth{})
+		if i == 2 {
+			AddComponent(w, e, benchVelocity{})
+		}
+	}
+	w.Init()
+	for e := range Query1[benchPosition](w) {
+		if len(despawned) < 2 {
+			despawned = append(despawned, e)
+		} else {
+			moving = append(moving, e)
+		}
+	}
+	RemoveEntity(w, despawned[0])
+	RemoveEntity(w, moving[0])
+	RemoveComponent[benchHealth](w, despawned[1])
+	w.RunUpdate(0)
+
+	var got []EntityID
+	for e, h := range Query1[benchHealth](w, Removed(Sig[benchHealth](w)), Optional(Sig[benchHealth](w)), Without(Sig[benchVelocity](w))) {
+		if h != nil {
+			t.Errorf("entity %v yielded with a component", e)
+		}
+		got = append(got, e)
+	}
+	if len(got) != 2 || got[0] != despawned[1] || got[1] != despawned[0] {
+		t.Errorf("Removed yielded %v, want %v and %v", got, despawned[1], despawned[0])
+	}
+	n := 0
+	for e, p := range Query1[benchPosition](w, Removed(Sig[benchPosition](w))) {
+		if e != despawned[0] && e != moving[0] || p != nil {
+			t.Errorf("Removed yielded %v with %v", e, p)
+		}
+		n++
+	}
+	if n != 2 {
+		t.Errorf("Removed yielded %d despawned entities, want 2", n)
+	}
+	w.RunUpdate(0)
+	for e := range Query1[benchPosition](w, Removed(Sig[benchPosition](w))) {
+		t.Errorf("Removed yielded %v again on the next update", e)
+	}
+}
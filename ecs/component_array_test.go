@@ -13,6 +13,8 @@ type componentArrayType interface {
 	remove(uint64, EntityID)
 }
 
+// componentArray is the map indexed per-component storage that preceded the
+// archetype tables. It is kept here as a baseline for the storage benchmarks.
 type componentArray[T any] struct {
 	entities    []entityComponent[T]
 	entityToIdx map[EntityID]int
@@ -2,165 +2,284 @@ package ecs
 
 import (
 	"fmt"
-	"iter"
 	"strings"
 )
 
 const initialEntityArraySize = 512
 
-type Signature uint64
-type EntityID uint64
+func newComponentManager(r *Registry) *componentManager {
+	cm := &componentManager{
+		Registry:     r,
+		archetypeIdx: make(map[string]*archetype),
+	}
+	cm.root, _ = cm.getArchetype(Signature{})
+	return cm
+}
 
-func (s Signature) Next() (Signature, bool) {
-	next := s << 1
-	if next == 0 {
-		return 0, false
+type componentInfo struct {
+	id        uint64
+	name      string
+	saveName  string
+	sig       Signature
+	newColumn func() columnType
+	tag       bool
+	sparse    bool
+	// newSet is set for the components stored in sparse sets.
+	newSet func() sparseStorage
+	// newRelation is set for the Relations components.
+	newRelation func(idx int) relationType
+	hooks       componentHooks
+	codec       componentCodec
+}
+
+// componentHooks are the type erased lifecycle hooks of a component.
+type componentHooks struct {
+	onAdd     func(*World, EntityID, columnType, int)
+	onRemove  func(*World, EntityID, columnType, int)
+	onReplace func(*World, EntityID, columnType, int)
+}
+
+// componentManager stores the components of a single world. The component types are
+// registered to the Registry, which may be shared with other worlds.
+type componentManager struct {
+	*Registry
+	archetypes   []*archetype
+	archetypeIdx map[string]*archetype
+	root         *archetype
+	// sets hold the sparse sets of the world by the component index. The sets are
+	// created when the first component is added.
+	sets []sparseStorage
+}
+
+// storage returns the sparse set of the component, or nil if the component is not
+// stored in a sparse set or none have been added yet.
+func (cm *componentManager) storage(idx int) sparseStorage {
+	if idx >= len(cm.sets) {
+		return nil
 	}
-	return next, true
+	return cm.sets[idx]
 }
 
-func newComponentManager() *componentManager {
-	return &componentManager{
-		componentArray:   make([]componentArrayType, 0, 64),
-		componentToIdx:   make(map[uint64]int, 64),
-		entitySignatures: make(map[uint64]Signature, initialEntityArraySize),
-		deadEntities:     make(map[uint64]struct{}, initialEntityArraySize),
-		sig:              1,
+// ensureStorage returns the sparse set of the component, creating it when needed.
+func (cm *componentManager) ensureStorage(idx int) sparseStorage {
+	for len(cm.sets) <= idx {
+		cm.sets = append(cm.sets, nil)
 	}
+	if cm.sets[idx] == nil {
+		cm.sets[idx] = cm.components[idx].newSet()
+	}
+	return cm.sets[idx]
 }
 
-type componentManager struct {
-	componentArray      []componentArrayType
-	componentSignatures []Signature
-	componentToIdx      map[uint64]int
-	entitySignatures    map[uint64]Signature
-	deadEntities        map[uint64]struct{}
-	sig                 Signature
-}
-
-func (cm *componentManager) iterateEntities(frame uint64, sig Signature) iter.Seq[EntityID] {
-	return func(yield func(EntityID) bool) {
-		for i := range cm.componentArray {
-			if cm.componentSignatures[i]&sig == 0 {
-				continue
-			}
-			for e := range cm.componentArray[i].iter(frame) {
-				if !yield(e) {
-					break
-				}
-			}
-		}
+// getArchetype returns the archetype for the signature, creating it when needed.
+func (cm *componentManager) getArchetype(sig Signature) (*archetype, bool) {
+	key := sig.key()
+	if a, ok := cm.archetypeIdx[key]; ok {
+		return a, false
 	}
+	a := newArchetype(sig, cm.components)
+	cm.archetypes = append(cm.archetypes, a)
+	cm.archetypeIdx[key] = a
+	return a, true
 }
 
-func (cm *componentManager) prune() {
-	for dead := range cm.deadEntities {
-		delete(cm.entitySignatures, dead)
+// withComponent returns the archetype reached by adding the component to a.
+func (cm *componentManager) withComponent(a *archetype, idx int) (*archetype, bool) {
+	if next, ok := a.addEdges[idx]; ok {
+		return next, false
 	}
-	clear(cm.deadEntities)
-	for _, ca := range cm.componentArray {
-		ca.prune()
+	next, created := cm.getArchetype(a.sig.with(idx))
+	a.addEdges[idx] = next
+	next.removeEdges[idx] = a
+	return next, created
+}
+
+// withoutComponent returns the archetype reached by removing the component from a.
+func (cm *componentManager) withoutComponent(a *archetype, idx int) (*archetype, bool) {
+	if prev, ok := a.removeEdges[idx]; ok {
+		return prev, false
+	}
+	prev, created := cm.getArchetype(a.sig.without(idx))
+	a.removeEdges[idx] = prev
+	prev.addEdges[idx] = a
+	return prev, created
+}
+
+// lookup returns the component index for the TypeID. Type ids are small
+// sequential integers, so the indices are kept in a slice instead of a map.
+func (r *Registry) lookup(id uint64) (int, bool) {
+	if id >= uint64(len(r.componentToIdx)) || r.componentToIdx[id] < 0 {
+		return 0, false
 	}
+	return r.componentToIdx[id], true
 }
 
-func (cm *componentManager) removeEntity(frame uint64, e EntityID) {
-	for _, ca := range cm.componentArray {
-		cm.deadEntities[uint64(e)] = struct{}{}
-		ca.remove(frame, e)
+func (r *Registry) setLookup(id uint64, idx int) {
+	for uint64(len(r.componentToIdx)) <= id {
+		r.componentToIdx = append(r.componentToIdx, -1)
 	}
+	r.componentToIdx[id] = idx
 }
 
-func hasComponentArray[T any](cm *componentManager) bool {
+func hasComponentArray[T any](r *Registry) bool {
 	var t T
-	name := TypeID(t)
-	_, ok := cm.componentToIdx[name]
+	_, ok := r.lookup(TypeID(t))
 	return ok
 }
 
 func getComponentIdx[T any](cm *componentManager) int {
-	var t T
-	name := TypeID(t)
-	idx, ok := cm.componentToIdx[name]
-	if !ok {
-		panic(fmt.Sprintf("component %v  is not registered with ComponentManager", name))
+	idx, err := componentIdx[T](cm)
+	if err != nil {
+		panic(err)
 	}
 	return idx
 }
 
-func addComponent[T any](frame uint64, cm *componentManager, e EntityID, c T) {
-	idx := getComponentIdx[T](cm)
-	ca := cm.componentArray[idx].(*componentArray[T])
-	sig := cm.entitySignatures[uint64(e)]
-	sig |= cm.componentSignatures[idx]
-	cm.entitySignatures[uint64(e)] = sig
-	ca.add(frame, e, c)
+// componentIdx is like getComponentIdx, but returns ErrNotRegistered instead of panicking.
+func componentIdx[T any](cm *componentManager) (int, error) {
+	var t T
+	idx, ok := cm.lookup(TypeID(t))
+	if !ok {
+		return 0, fmt.Errorf("%w: component %T", ErrNotRegistered, t)
+	}
+	return idx, nil
 }
 
-func debugPrintComponent[T any](frame uint64, cm *componentManager, e EntityID) string {
-	idx := getComponentIdx[T](cm)
-	return cm.componentArray[idx].debug(frame, e)
+func debugPrintComponent[T any](w *World, e EntityID) string {
+	idx := getComponentIdx[T](w.cm)
+	rec, ok := w.visible(e, idx)
+	if !ok {
+		return "<nil>"
+	}
+	col, row := w.cell(e, rec, idx)
+	if col == nil {
+		var t T
+		return fmt.Sprintf("%+v", t)
+	}
+	return col.debug(row)
 }
 
-func debugPrintEntity(frame uint64, cm *componentManager, e EntityID) string {
+func debugPrintEntity(em *entityManager, e EntityID) string {
+	rec, ok := em.get(e)
+	if !ok || rec.arch == nil {
+		return ""
+	}
 	var debugs []string
-	for _, ca := range cm.componentArray {
-		if ca.has(frame, e) {
-			debugs = append(debugs, ca.debug(frame, e))
-		}
+	for _, col := range rec.arch.chunks[rec.chunk].columns {
+		debugs = append(debugs, col.debug(rec.row))
 	}
 	return strings.Join(debugs, "\n")
 }
 
-func removeComponent[T any](frame uint64, cm *componentManager, e EntityID) {
-	idx := getComponentIdx[T](cm)
-	ca := cm.componentArray[idx].(*componentArray[T])
-	sig := cm.entitySignatures[uint64(e)]
-	sig &= ^cm.componentSignatures[idx]
-	cm.entitySignatures[uint64(e)] = sig
-	ca.remove(frame, e)
-}
-
-func registerComponent[T any](cm *componentManager) {
+func registerComponent[T any](r *Registry, opts []ComponentOption) {
 	var c T
-	var (
-		ok bool
-	)
-	if hasComponentArray[T](cm) {
+	if hasComponentArray[T](r) {
 		panic(fmt.Sprintf("component %T is already registered", c))
 	}
 
-	cm.componentArray = append(cm.componentArray, newComponentArray[T]())
-	cm.componentSignatures = append(cm.componentSignatures, cm.sig)
-	cm.componentToIdx[TypeID(c)] = len(cm.componentArray) - 1
-	cm.sig, ok = cm.sig.Next()
+	idx := len(r.components)
+	info := componentInfo{
+		id:        TypeID(c),
+		name:      fmt.Sprintf("%T", c),
+		saveName:  typeName[T](),
+		sig:       sigBit(idx),
+		newColumn: newColumn[T],
+		codec:     newComponentCodec[T](),
+	}
+	for _, opt := range opts {
+		opt.applyComponent(&info)
+	}
+	if info.sparse {
+		if info.tag {
+			panic(fmt.Sprintf("tag %T can not use sparse storage", c))
+		}
+		info.newColumn = nil
+		info.newSet = newSparseSet[T]
+	}
+	for _, other := range r.components {
+		if other.saveName == info.saveName {
+			panic(fmt.Sprintf("component %T has the same name %q as %s", c, info.saveName, other.name))
+		}
+	}
+	r.components = append(r.components, info)
+	r.setLookup(info.id, idx)
+}
+
+func getComponent[T any](w *World, e EntityID) *T {
+	idx := getComponentIdx[T](w.cm)
+	rec, ok := w.visible(e, idx)
 	if !ok {
-		panic("signature overflowed, too many registerd components")
+		return nil
+	}
+	col, row := w.cell(e, rec, idx)
+	if col == nil {
+		// Tags are zero-size, so this does not allocate.
+		return new(T)
 	}
+	return &col.(*column[T]).data[row]
 }
 
-func getComponent[T any](frame uint64, cm *componentManager, e EntityID) *T {
-	idx := getComponentIdx[T](cm)
-	ca := cm.componentArray[idx].(*componentArray[T])
-	return ca.Get(frame, e)
+// cell returns the column and the row that store the component of the entity. The
+// column is nil for tags.
+func (w *World) cell(e EntityID, rec *entityRecord, component int) (columnType, int) {
+	switch col := rec.arch.column(component); {
+	case col >= 0:
+		return rec.arch.chunks[rec.chunk].columns[col], rec.row
+	case col == sparseColumn:
+		c, row, _ := w.cm.storage(component).cell(e)
+		return c, row
+	}
+	return nil, 0
 }
 
-func hasComponent[T any](frame uint64, cm *componentManager, e EntityID) bool {
-	idx := getComponentIdx[T](cm)
-	ca := cm.componentArray[idx].(*componentArray[T])
-	return ca.has(frame, e)
+// setValue stores the value of the component when the entity is added to dst.
+func (w *World) setValue(e EntityID, rec *entityRecord, dst *archetype, component int, v any) {
+	switch col := dst.column(component); {
+	case col >= 0:
+		dst.chunks[rec.chunk].columns[col].set(rec.row, v, w.tick)
+	case col == sparseColumn:
+		w.cm.ensureStorage(component).add(e, v, w.tick)
+	}
 }
 
-func getComponentSignature[T any](cm *componentManager) Signature {
-	idx := getComponentIdx[T](cm)
-	return cm.componentSignatures[idx]
+// eachValue calls fn with every entity that has component T and with its value.
+// Changes that are still queued are not seen.
+func eachValue[T any](w *World, idx int, fn func(EntityID, *T)) {
+	if w.cm.components[idx].newSet != nil {
+		set := w.cm.storage(idx)
+		if set == nil {
+			return
+		}
+		s := set.(*sparseSet[T])
+		for i, e := range s.entities {
+			fn(e, &s.values.data[i])
+		}
+		return
+	}
+	var zero T
+	for _, a := range w.cm.archetypes {
+		if !a.sig.has(idx) {
+			continue
+		}
+		col := a.column(idx)
+		for _, ch := range a.chunks {
+			for i, e := range ch.entities {
+				if col < 0 {
+					fn(e, &zero)
+				} else {
+					fn(e, &ch.columns[col].(*column[T]).data[i])
+				}
+			}
+		}
+	}
 }
 
-type entityManager struct {
-	idx uint64
+func hasComponent[T any](w *World, e EntityID) bool {
+	_, ok := w.visible(e, getComponentIdx[T](w.cm))
+	return ok
 }
 
-func (em *entityManager) newEntity() EntityID {
-	e := em.idx
-	em.idx++
-	return EntityID(e)
+func getComponentSignature[T any](cm *componentManager) Signature {
+	idx := getComponentIdx[T](cm)
+	return cm.components[idx].sig
 }
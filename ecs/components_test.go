@@ -0,0 +1,29 @@
+package ecs
+
+import "testing"
+
+func TestHasComponentRemoved(t *testing.T) {
+	w := New()
+	RegisterComponent[benchPosition](w)
+	RegisterComponent[benchVelocity](w)
+	e := NewEntity(w)
+	AddComponent(w, e, benchPosition{})
+	AddComponent(w, e, benchVelocity{})
+	w.Init()
+
+	RemoveComponent[benchVelocity](w, e)
+	if HasComponent[benchVelocity](w, e) {
+		t.Error("HasComponent reports component queued for removal")
+	}
+	if !HasComponent[benchPosition](w, e) {
+		t.Error("HasComponent does not report the remaining component")
+	}
+	RemoveEntity(w, e)
+	if HasComponent[benchPosition](w, e) {
+		t.Error("HasComponent reports component of entity queued for removal")
+	}
+	w.RunUpdate(0)
+	if HasComponent[benchPosition](w, e) {
+		t.Error("HasComponent reports component of removed entity")
+	}
+}
@@ -0,0 +1,66 @@
+package ecs
+
+// Condition decides whether a system runs on the current update cycle.
+type Condition func(w *World) bool
+
+type conditionOption Condition
+
+func (o conditionOption) applySystem(se *systemEntry) {
+	se.conditions = append(se.conditions, Condition(o))
+}
+
+// RunIf makes the system run only when the condition holds. Conditions are evaluated
+// before each run of the system and the system runs only if all of them hold.
+//
+// Like disabled systems, the system keeps tracking its entities while it is not run.
+func RunIf(cond Condition) SystemOption {
+	return conditionOption(cond)
+}
+
+// SetSystemEnabled enables or disables the system of type T. Systems are enabled
+// when registered.
+//
+// Disabled systems are not run, but their entities are still kept up to date.
+// Change detection filters of the system see the changes made while it was
+// disabled once it is enabled again, except for the removals older than the
+// limit of the Removed filter.
+//
+// Calling this with unregistered system or system with multiple instances will panic,
+// use SetSystemHandleEnabled for those.
+func SetSystemEnabled[T SystemType](w *World, enabled bool) {
+	getSystem[T](w.sm).disabled = !enabled
+}
+
+// SystemEnabled reports whether the system of type T is enabled.
+//
+// Calling this with unregistered system or system with multiple instances will panic.
+func SystemEnabled[T SystemType](w *World) bool {
+	return !getSystem[T](w.sm).disabled
+}
+
+// SetSystemHandleEnabled enables or disables the system instance, see SetSystemEnabled.
+//
+// Calling this with unregistered handle will panic.
+func SetSystemHandleEnabled(w *World, h SystemHandle, enabled bool) {
+	w.sm.getHandle(h).disabled = !enabled
+}
+
+// SystemHandleEnabled reports whether the system instance is enabled.
+//
+// Calling this with unregistered handle will panic.
+func SystemHandleEnabled(w *World, h SystemHandle) bool {
+	return !w.sm.getHandle(h).disabled
+}
+
+// shouldRun reports whether the system is enabled and its run conditions hold.
+func (se *systemEntry) shouldRun(w *World) bool {
+	if se.disabled || se.unregistered {
+		return false
+	}
+	for _, cond := range se.conditions {
+		if !cond(w) {
+			return false
+		}
+	}
+	return true
+}
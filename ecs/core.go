@@ -10,6 +10,10 @@
 //
 // See GetComponent, AddComponent and RemoveComponent examples for more information.
 //
+// Misusing these functions, such as adding a component that the entity already has, will panic. Tools that
+// must survive bad input can use TryAddComponent, TryRemoveComponent and the other Try functions instead,
+// which return errors such as ErrNotRegistered and ErrNoSuchEntity.
+//
 // # Changes during updates
 //
 // Additions and removals of components and entities are done lazily. This also provides ecs system
@@ -20,19 +24,105 @@
 // update cycle. Changes to the component values themselves are always immediately visble to the other systems.
 //
 // Please see RegisterSystem (UpdateBarrier) example.
+//
+// # Change detection
+//
+// Components record when they were added and when they were last changed. Queries with Added, Changed
+// and Removed filters only yield entities that changed since the system last ran. Pointers from GetComponent
+// are not tracked, use GetComponentMut, MarkChanged or the Mutable query filter when the change should be seen.
+//
+// # Storage
+//
+// Entities that have the same set of components share an archetype. Archetype stores the components
+// in fixed size chunks column by column, so systems walking their entities read contiguous memory.
+// Structural changes move the entity between archetypes when they are applied at the end of the update cycle.
+// Components registered with SparseStorage and tags registered with RegisterTag are not stored in the chunks.
+//
+// # Parallel systems
+//
+// Systems run one at a time by default. With World.SetParallel, systems of the same stage that declare
+// their access with Reads, Writes, ReadsSingleton and WritesSingleton run in parallel when they do not
+// write data the others use. AddComponent, RemoveComponent, NewEntity, RemoveEntity and Send are safe to
+// call from parallel systems. World.DescribeSchedule tells which systems were serialized and why.
+//
+// ParallelForEach splits the entities of a single system between multiple workers.
+//
+// # Hierarchies and relations
+//
+// SetParent arranges the entities to a hierarchy that can be walked with Children, DepthFirst and
+// BreadthFirst. Removing an entity removes its descendants. Relations registered with RegisterRelation
+// link entities to any number of targets with data, see AddRelation, Targets and Sources. Both are
+// changed lazily like the components.
+//
+// # Multiple worlds
+//
+// Worlds created with the same Registry share their component registrations, so a loading screen or
+// UI can run in a world of its own. MoveEntity moves an entity with its components and descendants
+// from one such world to another.
+//
+// # Saving
+//
+// World.SaveJSON and World.SaveBinary write the entities with their components and the singletons
+// registered with Named. Components are identified by their names, so the saved worlds can be loaded
+// with World.LoadJSON and World.LoadBinary after the registration order has changed.
 package ecs
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+// ErrNotRegistered is returned when a component, a singleton or a relation has not been registered.
+var ErrNotRegistered = errors.New("ecs: type is not registered")
+
+// ErrNoSuchEntity is returned when an entity does not exist or has been removed.
+var ErrNoSuchEntity = errors.New("ecs: no such entity")
+
+// ErrAlreadyHas is returned when a component is added to an entity that already has it.
+var ErrAlreadyHas = errors.New("ecs: entity already has the component")
+
+// ErrNoComponent is returned when an entity does not have the requested component.
+var ErrNoComponent = errors.New("ecs: entity does not have the component")
 
 type World struct {
-	em   *entityManager
-	cm   *componentManager
-	sm   *systemManager
-	ism  *initSystemManger
-	sing *singletonManager
+	em     *entityManager
+	cm     *componentManager
+	sm     *systemManager
+	ism    *initSystemManger
+	sing   *singletonManager
+	events *eventManager
+
+	// mu guards the oplog, pending entity state and events, so that systems
+	// running in parallel can queue structural changes.
+	mu       sync.Mutex
+	oplog    []oplogEntry
+	removals atomic.Int64
+	frame    uint64
 
-	oplog []oplogEntry
-	frame uint64
+	// tick advances before every system run and every flush of the oplog.
+	// It is used to stamp component changes for change detection.
+	tick     uint64
+	prevTick uint64
+	removed  []removedComponent
+
+	fixed fixedTimestep
+	// restoreWords holds the pending signatures of the records restored from a snapshot.
+	restoreWords []uint64
+
+	// hierarchy is the index of the Hierarchy component and reparents the
+	// number of SetParent calls waiting in the oplog.
+	hierarchy int
+	reparents int
+	relations []relationType
+
+	shutdownSystems []ShutdownSystem
+	// closing is set when Shutdown starts and closed once the entities have
+	// been removed, as the shutdown systems and hooks still use the world.
+	closing bool
+	closed  bool
 }
 
 type oplogKind int
@@ -40,23 +130,37 @@ type oplogKind int
 const (
 	Delete oplogKind = iota
 	Add
+	Destroy
+	Create
+	Reparent
+	Relate
 )
 
 type oplogEntry struct {
-	Kind   oplogKind
-	Entity EntityID
-	Sig    Signature
+	Kind      oplogKind
+	Entity    EntityID
+	Component int
+	Value     any
 }
 
-func New() *World {
-	return &World{
-		em:    &entityManager{},
-		cm:    newComponentManager(),
-		sm:    newSystemManager(),
-		ism:   newInitSystemManager(),
-		sing:  newSingletonManager(),
-		frame: 0,
+// New creates new ecs world. Options such as WithRegistry can be given to configure it.
+func New(opts ...WorldOption) *World {
+	w := &World{
+		em:     newEntityManager(),
+		sm:     newSystemManager(),
+		ism:    newInitSystemManager(),
+		sing:   newSingletonManager(),
+		events: newEventManager(),
+		frame:  0,
+	}
+	for _, opt := range opts {
+		opt.applyWorld(w)
 	}
+	if w.cm == nil {
+		w.cm = newComponentManager(NewRegistry())
+	}
+	registerHierarchy(w)
+	return w
 }
 
 // Init prepares ecs world and runs all of the init systems.
@@ -64,51 +168,252 @@ func New() *World {
 // This should be called after all the initial components have been created,
 // and before the first RunUpdate call.
 func (w *World) Init() {
+	w.mustBuildSchedule()
 	w.cleanup()
 	w.frame++
 	for _, init := range w.ism.systems {
 		init.Init(w)
 	}
+	w.events.swap()
 }
 
 func (w *World) cleanup() {
-	for _, op := range w.oplog {
-		switch op.Kind {
-		case Add:
-			w.sm.addEntity(op.Entity, op.Sig)
-		case Delete:
-			w.sm.removeEntity(op.Entity, op.Sig)
-		}
+	w.tick++
+	// Hooks may queue more changes while the oplog is applied.
+	for i := 0; i < len(w.oplog); i++ {
+		w.apply(w.oplog[i])
 	}
+	clear(w.oplog)
 	w.oplog = w.oplog[:0]
-	w.cm.prune()
+	w.removals.Store(0)
+	w.reparents = 0
+	w.trimRemoved()
+	for _, r := range w.relations {
+		r.refresh(w)
+	}
 }
 
-// RunUpdate runs all of the Update systems and flushes all component additions or removals.
+// apply moves the entity to the archetype matching its new signature and
+// updates the system entity lists.
+func (w *World) apply(op oplogEntry) {
+	rec, ok := w.em.get(op.Entity)
+	if !ok {
+		return
+	}
+	defer w.updateRemoving(rec)
+	switch op.Kind {
+	case Create:
+		w.spawn(op.Entity, rec, op.Value)
+		return
+	case Reparent:
+		w.reparent(op.Entity, op.Value.(EntityID))
+		return
+	case Relate:
+		op.Value.(relationChange).apply(w, op.Entity)
+		return
+	}
+	oldSig := rec.arch.sig
+	switch op.Kind {
+	case Add:
+		dst, created := w.cm.withComponent(rec.arch, op.Component)
+		w.addArchetype(dst, created)
+		w.move(op.Entity, rec, dst)
+		w.setValue(op.Entity, rec, dst, op.Component, op.Value)
+		w.sm.updateEntity(op.Entity, oldSig, dst.sig)
+		w.runHook(w.cm.components[op.Component].hooks.onAdd, op.Entity, rec, op.Component)
+	case Delete:
+		w.runHook(w.cm.components[op.Component].hooks.onRemove, op.Entity, rec, op.Component)
+		w.removed = append(w.removed, removedComponent{entity: op.Entity, component: op.Component, tick: w.tick, frame: w.frame})
+		if set := w.cm.storage(op.Component); set != nil {
+			set.remove(op.Entity)
+		}
+		dst, created := w.cm.withoutComponent(rec.arch, op.Component)
+		w.addArchetype(dst, created)
+		w.move(op.Entity, rec, dst)
+		w.sm.updateEntity(op.Entity, oldSig, dst.sig)
+	case Destroy:
+		_, moved := op.Value.(movedOut)
+		w.runRemoveHooks(op.Entity, rec, moved)
+		if !rec.arch.sig.IsZero() {
+			w.removed = append(w.removed, removedComponent{entity: op.Entity, component: -1, tick: w.tick, frame: w.frame, despawned: rec.arch.sig})
+		}
+		for _, r := range w.relations {
+			r.targetRemoved(w, op.Entity)
+		}
+		for _, idx := range rec.arch.sparse {
+			w.cm.storage(idx).remove(op.Entity)
+		}
+		w.sm.removeEntity(op.Entity)
+		w.release(rec)
+		w.em.free(rec, op.Entity)
+	}
+}
+
+// addNow adds the component to the entity right away instead of queueing the addition.
+func (w *World) addNow(e EntityID, component int, value any) {
+	rec, _ := w.em.get(e)
+	w.mu.Lock()
+	rec.pending.set(component)
+	w.mu.Unlock()
+	w.apply(oplogEntry{Kind: Add, Entity: e, Component: component, Value: value})
+}
+
+// updateRemoving keeps the removing flag of the record in sync with the
+// removals still waiting in the oplog.
+func (w *World) updateRemoving(rec *entityRecord) {
+	var removing uint32
+	if rec.removed || (rec.arch != nil && !rec.pending.Contains(rec.arch.sig)) {
+		removing = 1
+	}
+	atomic.StoreUint32(&rec.removing, removing)
+}
+
+func (w *World) addArchetype(a *archetype, created bool) {
+	if created {
+		w.sm.addArchetype(a)
+	}
+}
+
+// move copies the entity's components to dst and frees its row in the old archetype.
+func (w *World) move(e EntityID, rec *entityRecord, dst *archetype) {
+	src := rec.arch
+	ci, row := dst.alloc(e)
+	srcChunk := src.chunks[rec.chunk]
+	dstChunk := dst.chunks[ci]
+	for i, idx := range dst.components {
+		if col := src.column(idx); col >= 0 {
+			srcChunk.columns[col].copyRow(dstChunk.columns[i], row, rec.row)
+		}
+	}
+	w.release(rec)
+	rec.arch, rec.chunk, rec.row = dst, ci, row
+}
+
+// release frees the entity's row in its current archetype.
+func (w *World) release(rec *entityRecord) {
+	if moved, ok := rec.arch.remove(rec.chunk, rec.row); ok {
+		w.em.record(moved.Index()).chunk = rec.chunk
+		w.em.record(moved.Index()).row = rec.row
+	}
+}
+
+// RunUpdate runs all of the Update systems stage by stage and flushes all component additions or removals.
 func (w *World) RunUpdate(dt float32) {
+	if w.closed {
+		panic(ErrClosed)
+	}
+	w.mustBuildSchedule()
+	start := w.tick
+	steps, alpha := w.fixed.advance(dt)
 	us := UpdateState{
 		World:     w,
 		DeltaTime: dt,
+		Alpha:     alpha,
 	}
-	for i, s := range w.sm.systems {
-		entities := w.sm.systemEntities[i]
-		us.Entities = entities
-		s.Update(us)
+	batches := w.sm.batches
+	fixedStart := w.sm.stageStart(FixedUpdate)
+	fixedEnd := w.sm.stageStart(FixedUpdate + 1)
+	w.runBatches(us, batches[:fixedStart])
+	if fixedEnd > fixedStart {
+		fixed := us
+		if w.fixed.step != 0 {
+			fixed.DeltaTime = float32(w.fixed.step)
+		}
+		fixed.Alpha = 0
+		w.events.collectFixed()
+		for range steps {
+			w.events.stepFixed()
+			w.runBatches(fixed, batches[fixedStart:fixedEnd])
+		}
 	}
+	w.runBatches(us, batches[fixedEnd:])
 	w.cleanup()
+	w.events.swap()
+	w.prevTick = start
 	w.frame++
 }
 
+func (w *World) runBatches(us UpdateState, batches [][]*systemEntry) {
+	for _, batch := range batches {
+		w.tick++
+		active := w.sm.active[:0]
+		for _, s := range batch {
+			if s.shouldRun(w) {
+				active = append(active, s)
+			}
+		}
+		w.sm.active = active
+		switch len(active) {
+		case 0:
+			continue
+		case 1:
+			runSystem(us, active[0])
+		default:
+			runParallel(us, active)
+		}
+		for _, s := range active {
+			s.lastRun = w.tick
+		}
+	}
+}
+
+func runSystem(us UpdateState, s *systemEntry) {
+	us.Entities = s.entities
+	us.system = s
+	s.system.Update(us)
+}
+
+// runParallel runs the systems of the batch in their own goroutines and waits
+// for all of them to finish.
+func runParallel(us UpdateState, batch []*systemEntry) {
+	var wg sync.WaitGroup
+	for _, s := range batch {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			runSystem(us, s)
+		}()
+	}
+	wg.Wait()
+}
+
 // NewEntity returns new EntityID handle
 func NewEntity(w *World) EntityID {
-	return w.em.newEntity()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		panic(ErrClosed)
+	}
+	e, _ := w.em.newEntity()
+	w.oplog = append(w.oplog, oplogEntry{Kind: Create, Entity: e})
+	return e
 }
 
 // RegisterComponent registers new component of type T.
 //
 // This needs to be called before component can be used.
-func RegisterComponent[T any](w *World) {
-	registerComponent[T](w.cm)
+//
+// Options such as Hooks and Named can be given to configure the component.
+func RegisterComponent[T any](w *World, opts ...ComponentOption) {
+	registerComponent[T](w.cm.Registry, opts)
+}
+
+// RegisterTag registers zero-size component of type T as a tag.
+//
+// Tags are stored only in the signature of the entity, so the chunks do not have a column
+// for them and no change ticks are recorded. Tags are still part of the archetype, so adding
+// and removing a tag moves the entity to another archetype and costs about as much as
+// adding and removing any other small component. Tags are used like the other components
+// with AddComponent, HasComponent, Sig and the filters, but Added and Changed filters ignore
+// them, as tags do not record when they were added or changed.
+//
+// Registering type that is not zero-size will panic.
+func RegisterTag[T any](w *World, opts ...ComponentOption) {
+	var t T
+	if unsafe.Sizeof(t) != 0 {
+		panic(fmt.Sprintf("tag %T is not zero-size", t))
+	}
+	registerComponent[T](w.cm.Registry, append([]ComponentOption{tagOption{}}, opts...))
 }
 
 // AddComponent adds component of type T to the Entity.
@@ -116,9 +421,89 @@ func RegisterComponent[T any](w *World) {
 // Calling this on non-existent entity or on entity that already
 // has the same component will panic.
 func AddComponent[T any](w *World, e EntityID, c T) {
-	addComponent(w.frame, w.cm, e, c)
-	sig := w.cm.entitySignatures[uint64(e)]
-	w.oplog = append(w.oplog, oplogEntry{Kind: Add, Entity: e, Sig: sig})
+	if err := TryAddComponent(w, e, c); err != nil {
+		panic(err)
+	}
+}
+
+// TryAddComponent is like AddComponent, but returns ErrNotRegistered, ErrNoSuchEntity,
+// ErrAlreadyHas or ErrClosed instead of panicking.
+func TryAddComponent[T any](w *World, e EntityID, c T) error {
+	idx, err := componentIdx[T](w.cm)
+	if err != nil {
+		return err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	rec, err := w.getPending(e)
+	if err != nil {
+		return err
+	}
+	if rec.pending.has(idx) {
+		return fmt.Errorf("%w: entity %v, component %T", ErrAlreadyHas, e, c)
+	}
+	rec.pending.set(idx)
+	w.oplog = append(w.oplog, oplogEntry{Kind: Add, Entity: e, Component: idx, Value: c})
+	return nil
+}
+
+// SetComponent sets the value of component T on the entity.
+//
+// If the entity already has the component, the value is overwritten right away and the
+// OnReplace hook is called with the old value. Otherwise the component is added like
+// with AddComponent.
+func SetComponent[T any](w *World, e EntityID, c T) {
+	if err := TrySetComponent(w, e, c); err != nil {
+		panic(err)
+	}
+}
+
+// TrySetComponent is like SetComponent, but returns ErrNotRegistered, ErrNoSuchEntity
+// or ErrClosed instead of panicking.
+func TrySetComponent[T any](w *World, e EntityID, c T) error {
+	idx, err := componentIdx[T](w.cm)
+	if err != nil {
+		return err
+	}
+	replace, err := queueSetComponent(w, e, idx, c)
+	if err != nil || !replace {
+		return err
+	}
+	rec, _ := w.em.get(e)
+	w.runHook(w.cm.components[idx].hooks.onReplace, e, rec, idx)
+	col, row := w.cell(e, rec, idx)
+	if col == nil {
+		return nil
+	}
+	col.(*column[T]).data[row] = c
+	col.ticks(row).changed = w.tick
+	return nil
+}
+
+// queueSetComponent queues the component to be added if the entity does not have it
+// yet. It returns true if the current value of the component should be replaced instead.
+func queueSetComponent[T any](w *World, e EntityID, idx int, c T) (bool, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	rec, err := w.getPending(e)
+	if err != nil {
+		return false, err
+	}
+	if !rec.pending.has(idx) {
+		rec.pending.set(idx)
+		w.oplog = append(w.oplog, oplogEntry{Kind: Add, Entity: e, Component: idx, Value: c})
+		return false, nil
+	}
+	if rec.arch == nil || !rec.arch.sig.has(idx) || w.removals.Load() > 0 {
+		// The component may be waiting in the oplog to be added.
+		for i := len(w.oplog) - 1; i >= 0; i-- {
+			if op := &w.oplog[i]; op.Kind == Add && op.Entity == e && op.Component == idx {
+				op.Value = c
+				return false, nil
+			}
+		}
+	}
+	return true, nil
 }
 
 // RemoveComponent removes component of type T from the Entity.
@@ -126,53 +511,155 @@ func AddComponent[T any](w *World, e EntityID, c T) {
 // Calling this on non-existent entity or on entity that does not
 // have the component will panic.
 func RemoveComponent[T any](w *World, e EntityID) {
-	sig := w.cm.entitySignatures[uint64(e)]
-	removeComponent[T](w.frame, w.cm, e)
-	w.oplog = append(w.oplog, oplogEntry{Kind: Delete, Entity: e, Sig: sig})
+	if err := TryRemoveComponent[T](w, e); err != nil {
+		panic(err)
+	}
+}
+
+// TryRemoveComponent is like RemoveComponent, but returns ErrNotRegistered, ErrNoSuchEntity,
+// ErrNoComponent or ErrClosed instead of panicking.
+func TryRemoveComponent[T any](w *World, e EntityID) error {
+	idx, err := componentIdx[T](w.cm)
+	if err != nil {
+		return err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	rec, err := w.getPending(e)
+	if err != nil {
+		return err
+	}
+	if !rec.pending.has(idx) {
+		var t T
+		return fmt.Errorf("%w: entity %v, component %T", ErrNoComponent, e, t)
+	}
+	rec.pending.unset(idx)
+	atomic.StoreUint32(&rec.removing, 1)
+	w.removals.Add(1)
+	w.oplog = append(w.oplog, oplogEntry{Kind: Delete, Entity: e, Component: idx})
+	return nil
 }
 
 // GetComponent returns component of type T attached to the entity.
 //
 // If the entity does not have the component, GetComponent will return nil.
 func GetComponent[T any](w *World, e EntityID) *T {
-	return getComponent[T](w.frame, w.cm, e)
+	return getComponent[T](w, e)
 }
 
 // MustGetComponent will return non-nil component of type T attached to the entity.
 //
 // Call to this will panic, if the the component does not exist on the entity.
 func MustGetComponent[T any](w *World, e EntityID) *T {
-	c := getComponent[T](w.frame, w.cm, e)
+	c := getComponent[T](w, e)
 	if c == nil {
 		var t T
-		panic(fmt.Sprintf("entity %d does not have component of type %T", e, t))
+		panic(fmt.Sprintf("entity %v does not have component of type %T", e, t))
 	}
 	return c
 }
 
+// TryGetComponent is like GetComponent, but returns ErrNotRegistered, ErrNoSuchEntity
+// or ErrNoComponent when the component can not be returned.
+func TryGetComponent[T any](w *World, e EntityID) (*T, error) {
+	if _, err := componentIdx[T](w.cm); err != nil {
+		return nil, err
+	}
+	if c := getComponent[T](w, e); c != nil {
+		return c, nil
+	}
+	if !IsAlive(w, e) {
+		return nil, fmt.Errorf("%w: %v", ErrNoSuchEntity, e)
+	}
+	var t T
+	return nil, fmt.Errorf("%w: entity %v, component %T", ErrNoComponent, e, t)
+}
+
 // RemoveEntity removes the entity and all of its associated components.
+// Descendants of the entity in the hierarchy are removed as well, see SetParent.
+//
+// The entity is considered dead right away, but its index is reused only after
+// the removal has been applied at the end of the update cycle.
+// Calling this on non-existent entity will panic.
 func RemoveEntity(w *World, e EntityID) {
-	sig := w.cm.entitySignatures[uint64(e)]
-	w.cm.removeEntity(w.frame, e)
-	w.oplog = append(w.oplog, oplogEntry{Kind: Delete, Entity: e, Sig: sig})
+	if err := TryRemoveEntity(w, e); err != nil {
+		panic(err)
+	}
+}
+
+// TryRemoveEntity is like RemoveEntity, but returns ErrNoSuchEntity or ErrClosed instead
+// of panicking.
+func TryRemoveEntity(w *World, e EntityID) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	rec, err := w.getPending(e)
+	if err != nil {
+		return err
+	}
+	w.queueRemoveEntity(e, rec)
+	w.removeDescendants(e)
+	return nil
+}
+
+// queueRemoveEntity marks the entity removed and queues its removal. The caller must hold World.mu.
+func (w *World) queueRemoveEntity(e EntityID, rec *entityRecord) {
+	rec.removed = true
+	atomic.StoreUint32(&rec.removing, 1)
+	w.removals.Add(1)
+	w.oplog = append(w.oplog, oplogEntry{Kind: Destroy, Entity: e})
+}
+
+// IsAlive reports whether the entity exists and has not been removed.
+//
+// It returns false for stale handles whose entity slot has been reused.
+func IsAlive(w *World, e EntityID) bool {
+	rec, ok := w.em.get(e)
+	if !ok {
+		return false
+	}
+	if atomic.LoadUint32(&rec.removing) != 0 {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		return !rec.removed
+	}
+	return true
 }
 
 // DebugComponent returns debug print of component T on entity.
 func DebugComponent[T any](w *World, e EntityID) string {
-	return debugPrintComponent[T](w.frame, w.cm, e)
+	return debugPrintComponent[T](w, e)
 }
 
 // RegisterSystem registers new Update system the ecs world.
-func RegisterSystem[T SystemType](w *World, s T, sig Signature) {
-	registerSystem(w.sm, s, sig)
-	idx := getSystemIdx[T](w.sm)
-	for e := range w.cm.iterateEntities(w.frame, sig) {
-		w.sm.systemEntities[idx] = append(w.sm.systemEntities[idx], e)
+//
+// The system receives the entities that have all of the components in sig.
+// Filters such as Without and Optional can be given as options to refine the match.
+// InStage, Before and After options control when the system runs.
+//
+// The same system type can be registered multiple times, for example with different
+// configuration. The returned SystemHandle identifies the registered instance.
+func RegisterSystem[T SystemType](w *World, s T, sig Signature, opts ...SystemOption) SystemHandle {
+	entry := registerSystem(w.sm, s, sig, opts)
+	for _, a := range w.cm.archetypes {
+		entry.addArchetype(a)
 	}
+	return entry.handle
+}
+
+// UnregisterSystem removes the system instance from the world.
+//
+// When called during an update cycle, the system is not run anymore on that cycle.
+// Calling this with unregistered handle will panic.
+func UnregisterSystem(w *World, h SystemHandle) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.sm.unregister(w.sm.getHandle(h))
 }
 
+// HasComponent reports whether the entity has component of type T. Like with
+// GetComponent, components and entities queued for removal are not seen.
 func HasComponent[T any](w *World, e EntityID) bool {
-	return hasComponent[T](w.frame, w.cm, e)
+	return hasComponent[T](w, e)
 }
 
 // RegisterInitSystem register new InitSystem to the ecs world.
@@ -183,8 +670,11 @@ func RegisterInitSystem(w *World, s InitSystem) {
 // RegisterSingleton registers singleton value to the ecs world.
 //
 // Calling this multiple times with same T will overwrite the previous value.
-func RegisterSingleton[T any](w *World, singleton *T) {
-	registerSingleton(w.sing, singleton)
+// Singletons implementing io.Closer are closed by World.Shutdown.
+//
+// Singletons are saved with the world only if they are given a name with Named.
+func RegisterSingleton[T any](w *World, singleton *T, opts ...SingletonOption) {
+	registerSingleton(w.sing, singleton, opts)
 }
 
 // GetSingleton returns previously registered singleton value.
@@ -194,7 +684,13 @@ func GetSingleton[T any](w *World) *T {
 	return getSingleton[T](w.sing)
 }
 
-// Sig will return Signature associated with component T.
+// TryGetSingleton is like GetSingleton, but returns ErrNotRegistered instead of panicking.
+func TryGetSingleton[T any](w *World) (*T, error) {
+	return lookupSingleton[T](w.sing)
+}
+
+// Sig will return Signature associated with component T. Signatures of multiple
+// components are combined with Signature.Or.
 func Sig[T any](w *World) Signature {
 	return getComponentSignature[T](w.cm)
 }
@@ -0,0 +1,235 @@
+package ecs
+
+import (
+	"fmt"
+	"slices"
+	"sync/atomic"
+)
+
+// EntityID is a handle to an entity.
+//
+// The lower 32 bits hold the index of the entity and the upper 32 bits its generation.
+// Indices of removed entities are reused with a new generation, so handles that outlive
+// their entity are never mistaken for the new entity. The zero EntityID never refers to
+// a live entity.
+type EntityID uint64
+
+func newEntityID(index, generation uint32) EntityID {
+	return EntityID(uint64(generation)<<32 | uint64(index))
+}
+
+// Index returns the slot of the entity. Indices are reused after the entity is removed.
+func (e EntityID) Index() uint32 {
+	return uint32(e)
+}
+
+// Generation returns the generation of the entity's slot.
+func (e EntityID) Generation() uint32 {
+	return uint32(e >> 32)
+}
+
+func (e EntityID) String() string {
+	return fmt.Sprintf("%d:%d", e.Index(), e.Generation())
+}
+
+// MarshalText encodes the entity in the same "index:generation" form as String.
+func (e EntityID) MarshalText() ([]byte, error) {
+	return []byte(e.String()), nil
+}
+
+// UnmarshalText decodes an entity encoded with MarshalText.
+func (e *EntityID) UnmarshalText(text []byte) error {
+	var index, generation uint32
+	if _, err := fmt.Sscanf(string(text), "%d:%d", &index, &generation); err != nil {
+		return fmt.Errorf("ecs: invalid entity %q: %w", text, err)
+	}
+	*e = newEntityID(index, generation)
+	return nil
+}
+
+const recordPageSize = 1024
+
+// entityRecord tells where the entity's components are stored.
+//
+// arch, chunk and row only change when the oplog is applied. The rest of the
+// fields are guarded by World.mu, except live and removing which are also read
+// atomically by the component accessors.
+type entityRecord struct {
+	arch  *archetype
+	chunk int
+	row   int
+
+	// pending holds the signature the entity will have after the queued
+	// structural changes have been applied.
+	pending    Signature
+	generation uint32
+	removed    bool
+
+	// live is the generation of the entity while it is alive and zero otherwise.
+	live uint32
+	// removing is set when the entity or some of its components are queued for removal.
+	removing uint32
+}
+
+type recordPage [recordPageSize]entityRecord
+
+// entityManager stores the entity records in fixed size pages. Pages never move,
+// so records can be read while other systems create new entities.
+type entityManager struct {
+	pages       atomic.Pointer[[]*recordPage]
+	len         atomic.Uint32
+	freeIndices []uint32
+}
+
+func newEntityManager() *entityManager {
+	em := &entityManager{}
+	em.pages.Store(&[]*recordPage{})
+	return em
+}
+
+func (em *entityManager) record(index uint32) *entityRecord {
+	pages := *em.pages.Load()
+	return &pages[index/recordPageSize][index%recordPageSize]
+}
+
+// newEntity reserves a record for the entity. The caller must hold World.mu.
+func (em *entityManager) newEntity() (EntityID, *entityRecord) {
+	var index uint32
+	if n := len(em.freeIndices); n > 0 {
+		index = em.freeIndices[n-1]
+		em.freeIndices = em.freeIndices[:n-1]
+	} else {
+		index = em.len.Load()
+		pages := *em.pages.Load()
+		if int(index/recordPageSize) == len(pages) {
+			grown := append(pages[:len(pages):len(pages)], new(recordPage))
+			em.pages.Store(&grown)
+		}
+		em.record(index).generation = 1
+		em.len.Store(index + 1)
+	}
+	rec := em.record(index)
+	atomic.StoreUint32(&rec.live, rec.generation)
+	return newEntityID(index, rec.generation), rec
+}
+
+// free releases the slot of a removed entity for reuse.
+func (em *entityManager) free(rec *entityRecord, e EntityID) {
+	rec.arch = nil
+	rec.pending = Signature{}
+	rec.removed = false
+	atomic.StoreUint32(&rec.removing, 0)
+	atomic.StoreUint32(&rec.live, 0)
+	rec.generation++
+	if rec.generation == 0 {
+		rec.generation = 1
+	}
+	em.freeIndices = append(em.freeIndices, e.Index())
+}
+
+// get returns the record of an entity that has not been removed yet.
+func (em *entityManager) get(e EntityID) (*entityRecord, bool) {
+	index := e.Index()
+	if index >= em.len.Load() {
+		return nil, false
+	}
+	rec := em.record(index)
+	return rec, atomic.LoadUint32(&rec.live) == e.Generation()
+}
+
+// visible returns the record if the entity currently has the component and it
+// is not queued for removal.
+func (w *World) visible(e EntityID, component int) (*entityRecord, bool) {
+	rec, ok := w.em.get(e)
+	if !ok || rec.arch == nil || !rec.arch.sig.has(component) {
+		return nil, false
+	}
+	if w.queuedForRemoval(rec, component) {
+		return nil, false
+	}
+	return rec, true
+}
+
+// queuedForRemoval reports whether the entity or its component is queued for removal.
+func (w *World) queuedForRemoval(rec *entityRecord, component int) bool {
+	if atomic.LoadUint32(&rec.removing) == 0 {
+		return false
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return rec.removed || !rec.pending.has(component)
+}
+
+// queuedForRemovalAny is like queuedForRemoval, but checks all components of sig.
+func (w *World) queuedForRemovalAny(rec *entityRecord, sig Signature) bool {
+	if atomic.LoadUint32(&rec.removing) == 0 {
+		return false
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return rec.removed || !rec.pending.Contains(sig)
+}
+
+// getPending returns the record of an entity that can still receive structural
+// changes, or ErrNoSuchEntity. The caller must hold World.mu.
+func (em *entityManager) getPending(e EntityID) (*entityRecord, error) {
+	rec, ok := em.get(e)
+	if !ok || rec.removed {
+		return nil, fmt.Errorf("%w: %v", ErrNoSuchEntity, e)
+	}
+	return rec, nil
+}
+
+// getPending is like entityManager.getPending, but returns ErrClosed once the world
+// has been shut down. The caller must hold World.mu.
+func (w *World) getPending(e EntityID) (*entityRecord, error) {
+	if w.closed {
+		return nil, ErrClosed
+	}
+	return w.em.getPending(e)
+}
+
+// mustGetPending is like getPending, but panics if the entity does not exist.
+func (em *entityManager) mustGetPending(e EntityID) *entityRecord {
+	rec, err := em.getPending(e)
+	if err != nil {
+		panic(err)
+	}
+	return rec
+}
+
+// restore recreates the records of saved entities to an empty entity manager.
+// Indices that are not used by the live entities are freed in the saved order.
+func (em *entityManager) restore(live, free []EntityID) error {
+	generations := make(map[uint32]uint32, len(live)+len(free))
+	n := uint32(0)
+	for _, e := range slices.Concat(live, free) {
+		if _, ok := generations[e.Index()]; ok || e.Generation() == 0 {
+			return fmt.Errorf("ecs: loading entities: invalid entity %v", e)
+		}
+		generations[e.Index()] = e.Generation()
+		n = max(n, e.Index()+1)
+	}
+	pages := make([]*recordPage, (n+recordPageSize-1)/recordPageSize)
+	for i := range pages {
+		pages[i] = new(recordPage)
+	}
+	em.pages.Store(&pages)
+	em.len.Store(n)
+	for index := range n {
+		rec := em.record(index)
+		rec.generation = 1
+		if gen, ok := generations[index]; ok {
+			rec.generation = gen
+		} else {
+			em.freeIndices = append(em.freeIndices, index)
+		}
+	}
+	for _, e := range free {
+		em.freeIndices = append(em.freeIndices, e.Index())
+	}
+	for _, e := range live {
+		atomic.StoreUint32(&em.record(e.Index()).live, e.Generation())
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package ecs
+
+type eventQueueType interface {
+	swap()
+	// collectFixed queues the readable events for the next fixed step.
+	collectFixed()
+	// stepFixed makes the queued events readable for the fixed step that is about to run.
+	stepFixed()
+	// copyInto copies the events to dst and returns it. New queue is allocated if dst is nil.
+	copyInto(dst eventQueueType) eventQueueType
+	// restore replaces the events with the events of src, or drops them if src is nil.
+	restore(src eventQueueType)
+}
+
+// eventQueue buffers events of type E. Events sent during an update cycle are
+// collected to pending and become readable on the next update cycle.
+//
+// FixedUpdate systems read the events from fixed instead, so that each event is
+// seen by exactly one fixed step no matter how many steps the update cycles run.
+type eventQueue[E any] struct {
+	pending []E
+	current []E
+	// fixedPending holds the events that no fixed step has seen yet.
+	fixedPending []E
+	fixed        []E
+}
+
+func (q *eventQueue[E]) swap() {
+	clear(q.current)
+	q.current, q.pending = q.pending, q.current[:0]
+}
+
+func (q *eventQueue[E]) collectFixed() {
+	q.fixedPending = append(q.fixedPending, q.current...)
+}
+
+func (q *eventQueue[E]) stepFixed() {
+	clear(q.fixed)
+	q.fixed, q.fixedPending = q.fixedPending, q.fixed[:0]
+}
+
+func (q *eventQueue[E]) copyInto(dst eventQueueType) eventQueueType {
+	d, _ := dst.(*eventQueue[E])
+	if d == nil {
+		d = &eventQueue[E]{}
+	}
+	d.pending = append(d.pending[:0], q.pending...)
+	d.current = append(d.current[:0], q.current...)
+	d.fixedPending = append(d.fixedPending[:0], q.fixedPending...)
+	d.fixed = append(d.fixed[:0], q.fixed...)
+	return d
+}
+
+func (q *eventQueue[E]) restore(src eventQueueType) {
+	clear(q.pending)
+	clear(q.current)
+	clear(q.fixedPending)
+	clear(q.fixed)
+	q.pending, q.current = q.pending[:0], q.current[:0]
+	q.fixedPending, q.fixed = q.fixedPending[:0], q.fixed[:0]
+	if s, ok := src.(*eventQueue[E]); ok {
+		q.pending = append(q.pending, s.pending...)
+		q.current = append(q.current, s.current...)
+		q.fixedPending = append(q.fixedPending, s.fixedPending...)
+		q.fixed = append(q.fixed, s.fixed...)
+	}
+}
+
+type eventManager struct {
+	queues map[uint64]eventQueueType
+}
+
+func newEventManager() *eventManager {
+	return &eventManager{
+		queues: make(map[uint64]eventQueueType),
+	}
+}
+
+func getEventQueue[E any](em *eventManager) *eventQueue[E] {
+	var e E
+	id := TypeID(e)
+	q, ok := em.queues[id].(*eventQueue[E])
+	if !ok {
+		q = &eventQueue[E]{}
+		em.queues[id] = q
+	}
+	return q
+}
+
+func (em *eventManager) swap() {
+	for _, q := range em.queues {
+		q.swap()
+	}
+}
+
+func (em *eventManager) collectFixed() {
+	for _, q := range em.queues {
+		q.collectFixed()
+	}
+}
+
+func (em *eventManager) stepFixed() {
+	for _, q := range em.queues {
+		q.stepFixed()
+	}
+}
+
+// Send sends event of type E.
+//
+// Like structural changes, events are not visible right away. Every system can
+// read the event with Read during the next update cycle, after which it is dropped.
+// Events sent by init systems are readable on the first update cycle.
+func Send[E any](w *World, event E) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	q := getEventQueue[E](w.events)
+	q.pending = append(q.pending, event)
+}
+
+// Read returns the events of type E sent during the previous update cycle.
+//
+// Systems in FixedUpdate stage read each event on only one fixed step, which is the
+// first step run after the event became readable. If an update cycle runs several
+// fixed steps, the later steps do not see the events again, and if it runs none, the
+// events are kept for the step of a later update cycle.
+//
+// The returned slice is shared between the systems and must not be modified.
+func Read[E any](us UpdateState) []E {
+	us.World.mu.Lock()
+	defer us.World.mu.Unlock()
+	q := getEventQueue[E](us.World.events)
+	if us.system != nil && us.system.stage == FixedUpdate {
+		return q.fixed
+	}
+	return q.current
+}
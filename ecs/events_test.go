@@ -0,0 +1,42 @@
+package ecs
+
+import (
+	"fmt"
+	"testing"
+)
+
+type benchEvent int
+
+// eventRecorder records the events that it reads on each run.
+type eventRecorder struct {
+	reads *[][]benchEvent
+}
+
+func (r eventRecorder) Update(us UpdateState) {
+	*r.reads = append(*r.reads, append([]benchEvent(nil), Read[benchEvent](us)...))
+}
+
+func TestFixedUpdateEvents(t *testing.T) {
+	w := New()
+	var fixed, update [][]benchEvent
+	RegisterSystem(w, eventRecorder{&fixed}, Signature{}, InStage(FixedUpdate))
+	RegisterSystem(w, eventRecorder{&update}, Signature{})
+	w.SetFixedTimestep(10, 5)
+	w.Init()
+
+	Send(w, benchEvent(1))
+	w.RunUpdate(0)
+	Send(w, benchEvent(2))
+	// No fixed steps, the events wait for the next step.
+	w.RunUpdate(0)
+	// Two fixed steps, only the first one sees the events.
+	w.RunUpdate(0.2)
+	w.RunUpdate(0.1)
+
+	if got, want := fmt.Sprint(fixed), "[[1 2] [] []]"; got != want {
+		t.Errorf("fixed steps read %v, want %v", got, want)
+	}
+	if got, want := fmt.Sprint(update), "[[] [1] [2] []]"; got != want {
+		t.Errorf("update systems read %v, want %v", got, want)
+	}
+}
@@ -1,7 +1,10 @@
 package ecs_test
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/MatiasLyyra/mengine/ecs"
 )
@@ -81,7 +84,7 @@ func ExampleRegisterSystem() {
 	player2 := ecs.NewEntity(w)
 	ecs.AddComponent(w, player2, Player{X: 300, Y: 500})
 	ecs.AddComponent(w, player2, Inventory{Food: 6})
-	ecs.RegisterSystem(w, PlayerUpdateSystem{}, ecs.Sig[Player](w)|ecs.Sig[Inventory](w))
+	ecs.RegisterSystem(w, PlayerUpdateSystem{}, ecs.Sig[Player](w).Or(ecs.Sig[Inventory](w)))
 	w.Init()
 	w.RunUpdate(0)
 	// Output:
@@ -122,7 +125,7 @@ func ExampleRegisterSystem_updateBarrier() {
 	player1 := ecs.NewEntity(w)
 	ecs.AddComponent(w, player1, Player{X: 200, Y: 400})
 	ecs.RegisterSystem(w, ModifyPlayerSystem{}, ecs.Sig[Player](w))
-	ecs.RegisterSystem(w, PrintPlayerSystem{}, ecs.Sig[Player](w)|ecs.Sig[Inventory](w))
+	ecs.RegisterSystem(w, PrintPlayerSystem{}, ecs.Sig[Player](w).Or(ecs.Sig[Inventory](w)))
 	w.Init()
 	// First update cycle
 	w.RunUpdate(0)
@@ -136,3 +139,840 @@ func ExampleRegisterSystem_updateBarrier() {
 	// PrintPlayerSystem.Update Player: &{X:200 Y:400}
 	// PrintPlayerSystem.Update Inventory: &{Food:10}
 }
+
+func ExampleSignature_Or() {
+	w := ecs.New()
+	ecs.RegisterComponent[Player](w)
+	ecs.RegisterComponent[Inventory](w)
+	sig := ecs.Sig[Player](w).Or(ecs.Sig[Inventory](w))
+	fmt.Println(sig.Contains(ecs.Sig[Player](w)), sig.Len())
+	// Output:
+	// true 2
+}
+
+func ExampleIsAlive() {
+	w := ecs.New()
+	ecs.RegisterComponent[Player](w)
+	player := ecs.NewEntity(w)
+	ecs.AddComponent(w, player, Player{X: 200, Y: 400})
+	w.Init()
+	ecs.RemoveEntity(w, player)
+	w.RunUpdate(0)
+	// The slot of the removed player is reused, but the old handle stays stale.
+	enemy := ecs.NewEntity(w)
+	ecs.AddComponent(w, enemy, Player{X: 10, Y: 10})
+	w.RunUpdate(0)
+	fmt.Println(enemy.Index() == player.Index())
+	fmt.Println(ecs.IsAlive(w, player), ecs.IsAlive(w, enemy))
+	fmt.Println(ecs.GetComponent[Player](w, player))
+	// Output:
+	// true
+	// false true
+	// <nil>
+}
+
+type FeedPlayerSystem struct{}
+
+func (FeedPlayerSystem) Update(us ecs.UpdateState) {
+	for e, r := range ecs.Query2[Player, Inventory](us) {
+		r.B.Food++
+		fmt.Printf("Entity %v Player: %+v Inventory: %+v\n", e, *r.A, *r.B)
+	}
+}
+
+func ExampleQuery2() {
+	w := ecs.New()
+	ecs.RegisterComponent[Player](w)
+	ecs.RegisterComponent[Inventory](w)
+	player1 := ecs.NewEntity(w)
+	ecs.AddComponent(w, player1, Player{X: 200, Y: 400})
+	player2 := ecs.NewEntity(w)
+	ecs.AddComponent(w, player2, Player{X: 300, Y: 500})
+	ecs.AddComponent(w, player2, Inventory{Food: 6})
+	ecs.RegisterSystem(w, FeedPlayerSystem{}, ecs.Sig[Player](w).Or(ecs.Sig[Inventory](w)))
+	w.Init()
+	w.RunUpdate(0)
+	// Queries can also be run outside of the systems.
+	for _, p := range ecs.Query1[Player](w) {
+		fmt.Printf("Player: %+v\n", *p)
+	}
+	// Output:
+	// Entity 1:1 Player: {X:300 Y:500} Inventory: {Food:7}
+	// Player: {X:200 Y:400}
+	// Player: {X:300 Y:500}
+}
+
+type Frozen struct{}
+
+type MovePlayerSystem struct{}
+
+func (MovePlayerSystem) Update(us ecs.UpdateState) {
+	for _, r := range ecs.Query2[Player, Inventory](us) {
+		r.A.X++
+		fmt.Printf("Player: %+v Inventory: %+v\n", *r.A, r.B)
+	}
+}
+
+func ExampleWithout() {
+	w := ecs.New()
+	ecs.RegisterComponent[Player](w)
+	ecs.RegisterComponent[Inventory](w)
+	ecs.RegisterComponent[Frozen](w)
+	player1 := ecs.NewEntity(w)
+	ecs.AddComponent(w, player1, Player{X: 200, Y: 400})
+	player2 := ecs.NewEntity(w)
+	ecs.AddComponent(w, player2, Player{X: 300, Y: 500})
+	ecs.AddComponent(w, player2, Inventory{Food: 6})
+	frozen := ecs.NewEntity(w)
+	ecs.AddComponent(w, frozen, Player{X: 0, Y: 0})
+	ecs.AddComponent(w, frozen, Frozen{})
+	// Inventory is handed to the system when present, frozen players are skipped.
+	ecs.RegisterSystem(w, MovePlayerSystem{}, ecs.Sig[Player](w),
+		ecs.Without(ecs.Sig[Frozen](w)),
+		ecs.Optional(ecs.Sig[Inventory](w)),
+	)
+	w.Init()
+	w.RunUpdate(0)
+	// Output:
+	// Player: {X:201 Y:400} Inventory: <nil>
+	// Player: {X:301 Y:500} Inventory: &{Food:6}
+}
+
+type SyncPlayerSystem struct{}
+
+func (SyncPlayerSystem) Update(us ecs.UpdateState) {
+	for e, p := range ecs.Query1[Player](us, ecs.Changed(ecs.Sig[Player](us.World))) {
+		fmt.Printf("Sync %v: %+v\n", e, *p)
+	}
+	for e := range ecs.Query1[Player](us, ecs.Removed(ecs.Sig[Inventory](us.World))) {
+		fmt.Printf("Inventory removed from %v\n", e)
+	}
+}
+
+// Systems using change detection filters see only the entities that have changed
+// since the system last ran.
+func ExampleChanged() {
+	w := ecs.New()
+	ecs.RegisterComponent[Player](w)
+	ecs.RegisterComponent[Inventory](w)
+	player1 := ecs.NewEntity(w)
+	ecs.AddComponent(w, player1, Player{X: 200, Y: 400})
+	ecs.AddComponent(w, player1, Inventory{Food: 2})
+	player2 := ecs.NewEntity(w)
+	ecs.AddComponent(w, player2, Player{X: 300, Y: 500})
+	ecs.RegisterSystem(w, SyncPlayerSystem{}, ecs.Sig[Player](w))
+	w.Init()
+	fmt.Println("Update 1")
+	w.RunUpdate(0)
+	fmt.Println("Update 2")
+	w.RunUpdate(0)
+	// Changes through GetComponent are not tracked.
+	ecs.GetComponent[Player](w, player1).X = 0
+	ecs.GetComponentMut[Player](w, player2).X = 0
+	ecs.RemoveComponent[Inventory](w, player1)
+	fmt.Println("Update 3")
+	w.RunUpdate(0)
+	fmt.Println("Update 4")
+	w.RunUpdate(0)
+	// Output:
+	// Update 1
+	// Sync 1:1: {X:300 Y:500}
+	// Sync 0:1: {X:200 Y:400}
+	// Update 2
+	// Update 3
+	// Sync 1:1: {X:0 Y:500}
+	// Update 4
+	// Inventory removed from 0:1
+}
+
+type Texture struct {
+	Name string
+}
+
+func ExampleHooks() {
+	w := ecs.New()
+	ecs.RegisterComponent[Texture](w, ecs.Hooks[Texture]{
+		OnAdd: func(w *ecs.World, e ecs.EntityID, t *Texture) {
+			fmt.Printf("Load %s\n", t.Name)
+		},
+		OnRemove: func(w *ecs.World, e ecs.EntityID, t *Texture) {
+			fmt.Printf("Unload %s\n", t.Name)
+		},
+		OnReplace: func(w *ecs.World, e ecs.EntityID, t *Texture) {
+			fmt.Printf("Replace %s\n", t.Name)
+		},
+	})
+	player := ecs.NewEntity(w)
+	ecs.AddComponent(w, player, Texture{Name: "player.png"})
+	w.Init()
+	ecs.SetComponent(w, player, Texture{Name: "player_hurt.png"})
+	ecs.RemoveEntity(w, player)
+	w.RunUpdate(0)
+	// Output:
+	// Load player.png
+	// Replace player.png
+	// Unload player_hurt.png
+}
+
+type DamageEvent struct {
+	Target ecs.EntityID
+	Amount int
+}
+
+type DamageSystem struct{}
+
+func (DamageSystem) Update(us ecs.UpdateState) {
+	for _, e := range us.Entities {
+		ecs.Send(us.World, DamageEvent{Target: e, Amount: 1})
+	}
+}
+
+type HealthSystem struct{}
+
+func (HealthSystem) Update(us ecs.UpdateState) {
+	for _, ev := range ecs.Read[DamageEvent](us) {
+		fmt.Printf("Entity %v took %d damage\n", ev.Target, ev.Amount)
+	}
+}
+
+// Events sent during an update cycle are read by the systems on the next update cycle.
+func ExampleSend() {
+	w := ecs.New()
+	ecs.RegisterComponent[Player](w)
+	player := ecs.NewEntity(w)
+	ecs.AddComponent(w, player, Player{})
+	ecs.RegisterSystem(w, HealthSystem{}, ecs.Sig[Player](w))
+	ecs.RegisterSystem(w, DamageSystem{}, ecs.Sig[Player](w))
+	w.Init()
+	fmt.Println("Update 1")
+	w.RunUpdate(0)
+	fmt.Println("Update 2")
+	w.RunUpdate(0)
+	// Output:
+	// Update 1
+	// Update 2
+	// Entity 0:1 took 1 damage
+}
+
+type InputSystem struct{}
+
+func (InputSystem) Update(ecs.UpdateState) { fmt.Println("InputSystem") }
+
+type PhysicsSystem struct{}
+
+func (PhysicsSystem) Update(ecs.UpdateState) { fmt.Println("PhysicsSystem") }
+
+type RenderSystem struct{}
+
+func (RenderSystem) Update(ecs.UpdateState) { fmt.Println("RenderSystem") }
+
+func ExampleAfter() {
+	w := ecs.New()
+	ecs.RegisterComponent[Player](w)
+	ecs.RegisterSystem(w, RenderSystem{}, ecs.Sig[Player](w), ecs.InStage(ecs.Render))
+	ecs.RegisterSystem(w, PhysicsSystem{}, ecs.Sig[Player](w), ecs.After[InputSystem]())
+	ecs.RegisterSystem(w, InputSystem{}, ecs.Sig[Player](w))
+	w.Init()
+	w.RunUpdate(0)
+	// Output:
+	// InputSystem
+	// PhysicsSystem
+	// RenderSystem
+}
+
+func ExampleWorld_BuildSchedule() {
+	w := ecs.New()
+	ecs.RegisterComponent[Player](w)
+	ecs.RegisterSystem(w, PhysicsSystem{}, ecs.Sig[Player](w), ecs.After[InputSystem]())
+	ecs.RegisterSystem(w, InputSystem{}, ecs.Sig[Player](w), ecs.After[PhysicsSystem]())
+	err := w.BuildSchedule()
+	fmt.Println(errors.Is(err, ecs.ErrScheduleCycle))
+	fmt.Println(err)
+	// Output:
+	// true
+	// ecs: system ordering constraints form a cycle in stage Update: ecs_test.PhysicsSystem -> ecs_test.InputSystem -> ecs_test.PhysicsSystem
+}
+
+type HungerSystem struct{}
+
+func (HungerSystem) Update(ecs.UpdateState) {}
+
+type MovementSystem struct{}
+
+func (MovementSystem) Update(ecs.UpdateState) {}
+
+type CameraSystem struct{}
+
+func (CameraSystem) Update(ecs.UpdateState) {}
+
+// Systems that declare their access run in parallel unless they conflict.
+func ExampleWorld_SetParallel() {
+	w := ecs.New()
+	ecs.RegisterComponent[Player](w)
+	ecs.RegisterComponent[Inventory](w)
+	w.SetParallel(true)
+	ecs.RegisterSystem(w, HungerSystem{}, ecs.Sig[Inventory](w), ecs.Writes(ecs.Sig[Inventory](w)))
+	ecs.RegisterSystem(w, MovementSystem{}, ecs.Sig[Player](w), ecs.Writes(ecs.Sig[Player](w)))
+	ecs.RegisterSystem(w, CameraSystem{}, ecs.Sig[Player](w), ecs.Reads(ecs.Sig[Player](w)))
+	ecs.RegisterSystem(w, RenderSystem{}, ecs.Sig[Player](w), ecs.InStage(ecs.Render))
+	fmt.Print(w.DescribeSchedule())
+	// Output:
+	// Update:
+	//   1: ecs_test.HungerSystem, ecs_test.MovementSystem
+	//   2: ecs_test.CameraSystem (ecs_test.MovementSystem writes ecs_test.Player which ecs_test.CameraSystem reads)
+	// Render:
+	//   3: ecs_test.RenderSystem
+}
+
+type DriftSystem struct{}
+
+func (DriftSystem) Update(us ecs.UpdateState) {
+	ecs.ParallelForEach(us, 64, func(e ecs.EntityID) {
+		p := ecs.GetComponent[Player](us.World, e)
+		p.X += p.Y
+	})
+}
+
+func ExampleParallelForEach() {
+	w := ecs.New()
+	ecs.RegisterComponent[Player](w)
+	for i := range 1000 {
+		ecs.AddComponent(w, ecs.NewEntity(w), Player{X: i, Y: 1})
+	}
+	ecs.RegisterSystem(w, DriftSystem{}, ecs.Sig[Player](w))
+	w.Init()
+	w.RunUpdate(0)
+	sum := 0
+	for _, p := range ecs.Query1[Player](w) {
+		sum += p.X
+	}
+	fmt.Println(sum)
+	// Output:
+	// 500500
+}
+
+type StepSystem struct{}
+
+func (StepSystem) Update(us ecs.UpdateState) { fmt.Printf("Step dt=%.2f\n", us.DeltaTime) }
+
+type InterpolateSystem struct{}
+
+func (InterpolateSystem) Update(us ecs.UpdateState) { fmt.Printf("Render alpha=%.2f\n", us.Alpha) }
+
+func ExampleWorld_SetFixedTimestep() {
+	w := ecs.New()
+	ecs.RegisterComponent[Player](w)
+	w.SetFixedTimestep(10, 3)
+	ecs.RegisterSystem(w, StepSystem{}, ecs.Sig[Player](w), ecs.InStage(ecs.FixedUpdate))
+	ecs.RegisterSystem(w, InterpolateSystem{}, ecs.Sig[Player](w), ecs.InStage(ecs.Render))
+	w.Init()
+	fmt.Println("Update 1")
+	w.RunUpdate(0.25)
+	fmt.Println("Update 2")
+	w.RunUpdate(0.02)
+	// Slow frames run at most 3 steps.
+	fmt.Println("Update 3")
+	w.RunUpdate(1)
+	// Output:
+	// Update 1
+	// Step dt=0.10
+	// Step dt=0.10
+	// Render alpha=0.50
+	// Update 2
+	// Render alpha=0.70
+	// Update 3
+	// Step dt=0.10
+	// Step dt=0.10
+	// Step dt=0.10
+	// Render alpha=0.70
+}
+
+type GameState int
+
+const (
+	Playing GameState = iota
+	Paused
+)
+
+type CountPlayersSystem struct{}
+
+func (CountPlayersSystem) Update(us ecs.UpdateState) {
+	fmt.Printf("Players: %d\n", len(us.Entities))
+}
+
+func ExampleRunIf() {
+	w := ecs.New()
+	ecs.RegisterComponent[Player](w)
+	state := Playing
+	ecs.RegisterSingleton(w, &state)
+	ecs.RegisterSystem(w, CountPlayersSystem{}, ecs.Sig[Player](w), ecs.RunIf(func(w *ecs.World) bool {
+		return *ecs.GetSingleton[GameState](w) == Playing
+	}))
+	ecs.AddComponent(w, ecs.NewEntity(w), Player{})
+	w.Init()
+	w.RunUpdate(0)
+	state = Paused
+	// The system is not run, but it keeps track of the new player.
+	ecs.AddComponent(w, ecs.NewEntity(w), Player{})
+	w.RunUpdate(0)
+	state = Playing
+	w.RunUpdate(0)
+	// Output:
+	// Players: 1
+	// Players: 2
+}
+
+func ExampleSetSystemEnabled() {
+	w := ecs.New()
+	ecs.RegisterComponent[Player](w)
+	ecs.RegisterSystem(w, CountPlayersSystem{}, ecs.Sig[Player](w))
+	ecs.AddComponent(w, ecs.NewEntity(w), Player{})
+	w.Init()
+	ecs.SetSystemEnabled[CountPlayersSystem](w, false)
+	w.RunUpdate(0)
+	ecs.SetSystemEnabled[CountPlayersSystem](w, true)
+	w.RunUpdate(0)
+	// Output:
+	// Players: 1
+}
+
+type DrawLayerSystem struct {
+	Layer int
+}
+
+func (s DrawLayerSystem) Update(us ecs.UpdateState) {
+	fmt.Printf("Draw layer %d\n", s.Layer)
+}
+
+// The same system type can be registered multiple times. The handles returned by
+// RegisterSystem identify the instances.
+func ExampleUnregisterSystem() {
+	w := ecs.New()
+	ecs.RegisterComponent[Player](w)
+	ecs.RegisterSystem(w, DrawLayerSystem{Layer: 0}, ecs.Sig[Player](w))
+	foreground := ecs.RegisterSystem(w, DrawLayerSystem{Layer: 1}, ecs.Sig[Player](w))
+	w.Init()
+	w.RunUpdate(0)
+	ecs.UnregisterSystem(w, foreground)
+	w.RunUpdate(0)
+	// Output:
+	// Draw layer 0
+	// Draw layer 1
+	// Draw layer 0
+}
+
+type AudioDevice struct{}
+
+func (*AudioDevice) Close() error {
+	fmt.Println("Close audio device")
+	return nil
+}
+
+type WindowSystem struct{}
+
+func (WindowSystem) Init(*ecs.World)     { fmt.Println("Open window") }
+func (WindowSystem) Shutdown(*ecs.World) { fmt.Println("Close window") }
+
+type SaveSystem struct{}
+
+func (SaveSystem) Shutdown(w *ecs.World) {
+	for _, t := range ecs.Query1[Texture](w) {
+		fmt.Printf("Save %s\n", t.Name)
+	}
+}
+
+func ExampleWorld_Shutdown() {
+	w := ecs.New()
+	ecs.RegisterComponent[Texture](w, ecs.Hooks[Texture]{
+		OnRemove: func(w *ecs.World, e ecs.EntityID, t *Texture) {
+			fmt.Printf("Unload %s\n", t.Name)
+		},
+	})
+	ecs.RegisterSingleton(w, &AudioDevice{})
+	ecs.RegisterInitSystem(w, WindowSystem{})
+	ecs.RegisterShutdownSystem(w, WindowSystem{})
+	ecs.RegisterShutdownSystem(w, SaveSystem{})
+	ecs.AddComponent(w, ecs.NewEntity(w), Texture{Name: "player.png"})
+	w.Init()
+	w.RunUpdate(0)
+	if err := w.Shutdown(); err != nil {
+		fmt.Println(err)
+	}
+	// Output:
+	// Open window
+	// Save player.png
+	// Close window
+	// Unload player.png
+	// Close audio device
+}
+
+func newSaveWorld() *ecs.World {
+	w := ecs.New()
+	ecs.RegisterComponent[Player](w, ecs.Named("player"))
+	ecs.RegisterComponent[Inventory](w, ecs.Named("inventory"))
+	state := Playing
+	ecs.RegisterSingleton(w, &state, ecs.Named("state"))
+	return w
+}
+
+func ExampleWorld_SaveJSON() {
+	w := newSaveWorld()
+	player := ecs.NewEntity(w)
+	ecs.AddComponent(w, player, Player{X: 200, Y: 400})
+	ecs.AddComponent(w, player, Inventory{Food: 2})
+	ecs.AddComponent(w, ecs.NewEntity(w), Player{X: 10, Y: 20})
+	*ecs.GetSingleton[GameState](w) = Paused
+	w.Init()
+	var b bytes.Buffer
+	if err := w.SaveJSON(&b); err != nil {
+		fmt.Println(err)
+	}
+	fmt.Print(b.String())
+
+	loaded := newSaveWorld()
+	if err := loaded.LoadJSON(&b); err != nil {
+		fmt.Println(err)
+	}
+	fmt.Println(ecs.DebugComponent[Inventory](loaded, player))
+	fmt.Println(*ecs.GetSingleton[GameState](loaded))
+	// Output:
+	// {
+	//   "entities": [
+	//     "0:1",
+	//     "1:1"
+	//   ],
+	//   "components": [
+	//     {
+	//       "name": "player",
+	//       "entities": [
+	//         "1:1",
+	//         "0:1"
+	//       ],
+	//       "values": [
+	//         {
+	//           "X": 10,
+	//           "Y": 20
+	//         },
+	//         {
+	//           "X": 200,
+	//           "Y": 400
+	//         }
+	//       ]
+	//     },
+	//     {
+	//       "name": "inventory",
+	//       "entities": [
+	//         "0:1"
+	//       ],
+	//       "values": [
+	//         {
+	//           "Food": 2
+	//         }
+	//       ]
+	//     }
+	//   ],
+	//   "singletons": [
+	//     {
+	//       "name": "state",
+	//       "value": 1
+	//     }
+	//   ]
+	// }
+	// {Food:2}
+	// 1
+}
+
+func ExampleWorld_LoadBinary() {
+	w := newSaveWorld()
+	player := ecs.NewEntity(w)
+	ecs.AddComponent(w, player, Player{X: 200, Y: 400})
+	ecs.AddComponent(w, player, Inventory{Food: 2})
+	w.Init()
+	var b bytes.Buffer
+	if err := w.SaveBinary(&b); err != nil {
+		fmt.Println(err)
+	}
+
+	// Inventory is not registered to the world that loads the save.
+	loaded := ecs.New()
+	ecs.RegisterComponent[Player](loaded, ecs.Named("player"))
+	err := loaded.LoadBinary(&b)
+	fmt.Println(errors.Is(err, ecs.ErrUnknownType))
+	fmt.Println(err)
+	// Output:
+	// true
+	// ecs: unknown type in saved world: component "inventory" is not registered
+}
+
+func ExampleWorld_Restore() {
+	w := ecs.New()
+	ecs.RegisterComponent[Player](w)
+	player := ecs.NewEntity(w)
+	ecs.AddComponent(w, player, Player{X: 200, Y: 400})
+	w.Init()
+	snap := w.Snapshot()
+
+	ecs.GetComponent[Player](w, player).X = 0
+	ecs.RemoveEntity(w, player)
+	w.RunUpdate(0)
+	fmt.Println(ecs.IsAlive(w, player))
+
+	w.Restore(snap)
+	fmt.Println(ecs.IsAlive(w, player))
+	fmt.Println(ecs.DebugComponent[Player](w, player))
+	// Output:
+	// false
+	// true
+	// {X:200 Y:400}
+}
+
+func ExampleSpawn() {
+	w := ecs.New()
+	ecs.RegisterComponent[Player](w)
+	ecs.RegisterComponent[Inventory](w)
+	prefab := ecs.NewPrefab("player", Player{X: 200, Y: 400}, Inventory{Food: 2})
+	player1 := ecs.Spawn(w, prefab)
+	player2 := ecs.Spawn(w, prefab, Player{X: 10, Y: 20})
+	w.Init()
+	fmt.Println(ecs.DebugComponent[Player](w, player1), ecs.DebugComponent[Inventory](w, player1))
+	fmt.Println(ecs.DebugComponent[Player](w, player2), ecs.DebugComponent[Inventory](w, player2))
+	// Output:
+	// {X:200 Y:400} {Food:2}
+	// {X:10 Y:20} {Food:2}
+}
+
+func ExampleLoadPrefabs() {
+	w := ecs.New()
+	ecs.RegisterComponent[Player](w, ecs.Named("player"))
+	ecs.RegisterComponent[Inventory](w, ecs.Named("inventory"))
+	prefabs, err := ecs.LoadPrefabs(w, strings.NewReader(`{
+		"merchant": {
+			"player": {"X": 50},
+			"inventory": {"Food": 10}
+		}
+	}`))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	merchant := ecs.Spawn(w, prefabs["merchant"])
+	w.Init()
+	fmt.Println(ecs.DebugComponent[Player](w, merchant), ecs.DebugComponent[Inventory](w, merchant))
+	// Output:
+	// {X:50 Y:0} {Food:10}
+}
+
+type Name string
+
+func ExampleSetParent() {
+	w := ecs.New()
+	ecs.RegisterComponent[Name](w)
+	entity := func(name string) ecs.EntityID {
+		e := ecs.NewEntity(w)
+		ecs.AddComponent(w, e, Name(name))
+		return e
+	}
+	tank := entity("tank")
+	turret := entity("turret")
+	barrel := entity("barrel")
+	tracks := entity("tracks")
+	smoke := entity("smoke")
+	ecs.SetParent(w, turret, tank)
+	ecs.SetParent(w, barrel, turret)
+	ecs.SetParent(w, tracks, tank)
+	ecs.SetParent(w, smoke, barrel)
+	w.Init()
+
+	names := func(seq func(func(ecs.EntityID) bool)) []Name {
+		var names []Name
+		for e := range seq {
+			names = append(names, *ecs.GetComponent[Name](w, e))
+		}
+		return names
+	}
+	fmt.Println(names(ecs.Children(w, tank)))
+	fmt.Println(names(ecs.DepthFirst(w, tank)))
+	fmt.Println(names(ecs.BreadthFirst(w, tank)))
+
+	// Removing the turret removes the barrel and the smoke as well.
+	ecs.RemoveEntity(w, turret)
+	fmt.Println(ecs.IsAlive(w, barrel), ecs.IsAlive(w, smoke))
+	w.RunUpdate(0)
+	fmt.Println(names(ecs.DepthFirst(w, tank)))
+	// Output:
+	// [turret tracks]
+	// [turret barrel smoke tracks]
+	// [turret tracks barrel smoke]
+	// false false
+	// [tracks]
+}
+
+type Targets struct {
+	Priority int
+}
+
+type DockedAt struct{}
+
+func ExampleRegisterRelation() {
+	w := ecs.New()
+	ecs.RegisterComponent[Name](w)
+	// Targeting stops when the target is removed, but docked ships go down with the station.
+	ecs.RegisterRelation[Targets](w, ecs.DropRelation)
+	ecs.RegisterRelation[DockedAt](w, ecs.RemoveSource)
+	entity := func(name string) ecs.EntityID {
+		e := ecs.NewEntity(w)
+		ecs.AddComponent(w, e, Name(name))
+		return e
+	}
+	name := func(e ecs.EntityID) Name {
+		return *ecs.GetComponent[Name](w, e)
+	}
+	turret := entity("turret")
+	fighter := entity("fighter")
+	freighter := entity("freighter")
+	station := entity("station")
+	ecs.AddRelation(w, turret, fighter, Targets{Priority: 2})
+	ecs.AddRelation(w, turret, freighter, Targets{Priority: 1})
+	ecs.AddRelation(w, fighter, freighter, Targets{Priority: 1})
+	ecs.AddRelation(w, freighter, station, DockedAt{})
+	w.Init()
+
+	for target, t := range ecs.Targets[Targets](w, turret) {
+		fmt.Println("turret targets", name(target), "with priority", t.Priority)
+	}
+	for source := range ecs.Sources[Targets](w, freighter) {
+		fmt.Println(name(source), "targets freighter")
+	}
+
+	ecs.RemoveEntity(w, station)
+	w.RunUpdate(0)
+	fmt.Println(ecs.IsAlive(w, freighter))
+	for target := range ecs.Targets[Targets](w, turret) {
+		fmt.Println("turret targets", name(target))
+	}
+	// Output:
+	// turret targets fighter with priority 2
+	// turret targets freighter with priority 1
+	// turret targets freighter
+	// fighter targets freighter
+	// false
+	// turret targets fighter
+}
+
+type Enemy struct{}
+
+type EnemyAISystem struct{}
+
+func (EnemyAISystem) Update(us ecs.UpdateState) {
+	for e, p := range ecs.Query1[Player](us) {
+		p.X++
+		fmt.Printf("Enemy %v moves to %d\n", e, p.X)
+	}
+}
+
+func ExampleRegisterTag() {
+	w := ecs.New()
+	ecs.RegisterComponent[Player](w)
+	// Tags are not stored in the chunks, but they are part of the archetype like other components.
+	ecs.RegisterTag[Enemy](w)
+	ecs.RegisterTag[Frozen](w)
+	for i := range 3 {
+		e := ecs.NewEntity(w)
+		ecs.AddComponent(w, e, Player{X: i * 10})
+		ecs.AddComponent(w, e, Enemy{})
+		if i == 1 {
+			ecs.AddComponent(w, e, Frozen{})
+		}
+	}
+	player := ecs.NewEntity(w)
+	ecs.AddComponent(w, player, Player{})
+	ecs.RegisterSystem(w, EnemyAISystem{}, ecs.Sig[Player](w).Or(ecs.Sig[Enemy](w)), ecs.Without(ecs.Sig[Frozen](w)))
+	w.Init()
+	w.RunUpdate(0)
+	fmt.Println(ecs.HasComponent[Enemy](w, player))
+	// Output:
+	// Enemy 0:1 moves to 1
+	// Enemy 2:1 moves to 21
+	// false
+}
+
+type Pathfinding struct {
+	Path [64]Player
+	Len  int
+}
+
+func ExampleSparseStorage() {
+	w := ecs.New()
+	ecs.RegisterComponent[Player](w)
+	// The large path is not copied when Frozen is added and removed.
+	ecs.RegisterComponent[Pathfinding](w, ecs.SparseStorage())
+	ecs.RegisterTag[Frozen](w)
+	e := ecs.NewEntity(w)
+	ecs.AddComponent(w, e, Player{})
+	ecs.AddComponent(w, e, Pathfinding{Len: 1})
+	w.Init()
+	ecs.AddComponent(w, e, Frozen{})
+	w.RunUpdate(0)
+	ecs.RemoveComponent[Frozen](w, e)
+	w.RunUpdate(0)
+	for _, r := range ecs.Query2[Player, Pathfinding](w) {
+		fmt.Println(r.B.Len)
+	}
+	// Output:
+	// 1
+}
+
+func ExampleMoveEntity() {
+	registry := ecs.NewRegistry()
+	loading := ecs.New(ecs.WithRegistry(registry))
+	game := ecs.New(ecs.WithRegistry(registry))
+	// Components are registered once for both of the worlds.
+	ecs.RegisterComponent[Player](loading)
+	ecs.RegisterComponent[Name](game)
+	player := ecs.NewEntity(loading)
+	ecs.AddComponent(loading, player, Player{X: 5})
+	ecs.AddComponent(loading, player, Name("player"))
+	weapon := ecs.NewEntity(loading)
+	ecs.AddComponent(loading, weapon, Name("sword"))
+	ecs.SetParent(loading, weapon, player)
+	loading.Init()
+	game.Init()
+
+	player = ecs.MoveEntity(loading, game, player)
+	loading.RunUpdate(0)
+	game.RunUpdate(0)
+	fmt.Println(ecs.IsAlive(loading, weapon))
+	fmt.Println(*ecs.GetComponent[Name](game, player), ecs.GetComponent[Player](game, player).X)
+	for c := range ecs.Children(game, player) {
+		fmt.Println(*ecs.GetComponent[Name](game, c))
+	}
+	// Output:
+	// false
+	// player 5
+	// sword
+}
+
+func ExampleTryAddComponent() {
+	w := ecs.New()
+	ecs.RegisterComponent[Player](w)
+	player := ecs.NewEntity(w)
+	fmt.Println(ecs.TryAddComponent(w, player, Player{X: 200}))
+	// Errors can be reported instead of crashing, for example in a console.
+	err := ecs.TryAddComponent(w, player, Player{X: 300})
+	fmt.Println(errors.Is(err, ecs.ErrAlreadyHas))
+	fmt.Println(err)
+	err = ecs.TryAddComponent(w, player, Inventory{Food: 2})
+	fmt.Println(errors.Is(err, ecs.ErrNotRegistered))
+	ecs.RemoveEntity(w, player)
+	err = ecs.TryRemoveComponent[Player](w, player)
+	fmt.Println(errors.Is(err, ecs.ErrNoSuchEntity))
+	_, err = ecs.TryGetSingleton[Inventory](w)
+	fmt.Println(err)
+	// Output:
+	// <nil>
+	// true
+	// ecs: entity already has the component: entity 0:1, component ecs_test.Player
+	// true
+	// true
+	// ecs: type is not registered: singleton ecs_test.Inventory
+}
@@ -0,0 +1,102 @@
+package ecs
+
+type filterKind int
+
+const (
+	withoutFilter filterKind = iota
+	optionalFilter
+	addedFilter
+	changedFilter
+	removedFilter
+	mutableFilter
+)
+
+// Filter narrows down the entities matched by a system or a query.
+//
+// Filters can be passed to RegisterSystem as system options and to the Query functions.
+// Change detection filters (Added, Changed, Removed and Mutable) do not affect which
+// entities the system receives, they apply to the queries run with its UpdateState.
+type Filter struct {
+	kind filterKind
+	sig  Signature
+}
+
+// Without excludes entities that have any of the components in sig.
+func Without(sig Signature) Filter {
+	return Filter{kind: withoutFilter, sig: sig}
+}
+
+// Optional marks the components in sig as not required for a match.
+//
+// Queries yield nil for optional components that the entity does not have.
+func Optional(sig Signature) Filter {
+	return Filter{kind: optionalFilter, sig: sig}
+}
+
+// Added matches entities that got any of the components in sig since the system last ran.
+//
+// When used outside of a system, changes since the start of the last completed update are matched.
+func Added(sig Signature) Filter {
+	return Filter{kind: addedFilter, sig: sig}
+}
+
+// Changed matches entities where any of the components in sig was added or marked
+// changed since the system last ran.
+//
+// Components are marked changed by GetComponentMut, MarkChanged and queries using Mutable.
+func Changed(sig Signature) Filter {
+	return Filter{kind: changedFilter, sig: sig}
+}
+
+// Removed matches entities that lost any of the components in sig since the system last ran.
+//
+// Entities removed with RemoveEntity are matched as well, if the query would have matched
+// them before the removal. As they no longer exist, queries yield them after the other
+// entities with nil components.
+//
+// Removals are remembered for 64 frames, so systems that have not run for longer, such
+// as disabled systems, do not see the older removals.
+func Removed(sig Signature) Filter {
+	return Filter{kind: removedFilter, sig: sig}
+}
+
+// Mutable marks the components in sig changed for every entity the query yields.
+func Mutable(sig Signature) Filter {
+	return Filter{kind: mutableFilter, sig: sig}
+}
+
+func (f Filter) applySystem(se *systemEntry) {
+	se.terms.add(f)
+}
+
+// terms holds the combined filters of a system or a query.
+type terms struct {
+	without  Signature
+	optional Signature
+	added    Signature
+	changed  Signature
+	removed  Signature
+	mutable  Signature
+}
+
+func (t *terms) add(f Filter) {
+	switch f.kind {
+	case withoutFilter:
+		t.without = t.without.Or(f.sig)
+	case optionalFilter:
+		t.optional = t.optional.Or(f.sig)
+	case addedFilter:
+		t.added = t.added.Or(f.sig)
+	case changedFilter:
+		t.changed = t.changed.Or(f.sig)
+	case removedFilter:
+		t.removed = t.removed.Or(f.sig)
+	case mutableFilter:
+		t.mutable = t.mutable.Or(f.sig)
+	}
+}
+
+// SystemOption configures a system registered with RegisterSystem.
+type SystemOption interface {
+	applySystem(*systemEntry)
+}
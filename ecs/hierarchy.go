@@ -0,0 +1,259 @@
+package ecs
+
+import (
+	"fmt"
+	"iter"
+)
+
+// Hierarchy links an entity to its parent and to its siblings. It is added to both
+// the child and the parent by SetParent and should not be modified directly.
+//
+// The children of an entity form a linked list, which keeps the component a plain
+// value that is copied by snapshots and saved with the world.
+type Hierarchy struct {
+	Parent      EntityID
+	FirstChild  EntityID
+	LastChild   EntityID
+	PrevSibling EntityID
+	NextSibling EntityID
+}
+
+func registerHierarchy(w *World) {
+	if !hasComponentArray[Hierarchy](w.cm.Registry) {
+		RegisterComponent[Hierarchy](w, Hooks[Hierarchy]{OnRemove: onHierarchyRemove}, Named("ecs.Hierarchy"))
+	}
+	w.hierarchy = getComponentIdx[Hierarchy](w.cm)
+}
+
+// SetParent makes child a child of parent. Children are kept in the order they were
+// added. Zero parent detaches the child from its current parent.
+//
+// Like structural changes, the change is applied at the end of the update cycle, after
+// which it is visible to Parent, Children and the traversals. The Hierarchy component
+// is added to both entities as needed.
+//
+// Calling this on non-existent entities or with parent that is the child itself or one
+// of its descendants will panic. Changes that are still queued are taken into account.
+func SetParent(w *World, child, parent EntityID) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.em.mustGetPending(child)
+	if parent != 0 {
+		w.em.mustGetPending(parent)
+	}
+	for p := parent; p != 0; p = w.pendingParent(p) {
+		if p == child {
+			panic(fmt.Sprintf("entity %v can not be a child of its descendant %v", child, parent))
+		}
+	}
+	w.reparents++
+	w.oplog = append(w.oplog, oplogEntry{Kind: Reparent, Entity: child, Value: parent})
+}
+
+// Parent returns the parent of the entity, or false if it does not have one.
+func Parent(w *World, e EntityID) (EntityID, bool) {
+	n := w.node(e)
+	if n == nil || n.Parent == 0 || !IsAlive(w, n.Parent) {
+		return 0, false
+	}
+	return n.Parent, true
+}
+
+// Children iterates over the children of the entity. Children that have been
+// removed with RemoveEntity are skipped.
+func Children(w *World, e EntityID) iter.Seq[EntityID] {
+	return func(yield func(EntityID) bool) {
+		n := w.node(e)
+		if n == nil {
+			return
+		}
+		for c := n.FirstChild; c != 0; c = w.node(c).NextSibling {
+			if IsAlive(w, c) && !yield(c) {
+				return
+			}
+		}
+	}
+}
+
+// DepthFirst iterates over the descendants of the entity in depth-first order,
+// visiting every entity before its children. The entity itself is not included.
+func DepthFirst(w *World, e EntityID) iter.Seq[EntityID] {
+	return func(yield func(EntityID) bool) {
+		var visit func(e EntityID) bool
+		visit = func(e EntityID) bool {
+			for c := range Children(w, e) {
+				if !yield(c) || !visit(c) {
+					return false
+				}
+			}
+			return true
+		}
+		visit(e)
+	}
+}
+
+// BreadthFirst iterates over the descendants of the entity in breadth-first order.
+// The entity itself is not included.
+func BreadthFirst(w *World, e EntityID) iter.Seq[EntityID] {
+	return func(yield func(EntityID) bool) {
+		queue := []EntityID{e}
+		for len(queue) > 0 {
+			next := queue[0]
+			queue = queue[1:]
+			for c := range Children(w, next) {
+				if !yield(c) {
+					return
+				}
+				queue = append(queue, c)
+			}
+		}
+	}
+}
+
+// node returns the Hierarchy component of the entity, or nil if it does not have one.
+func (w *World) node(e EntityID) *Hierarchy {
+	rec, ok := w.em.get(e)
+	if !ok || rec.arch == nil {
+		return nil
+	}
+	col := rec.arch.column(w.hierarchy)
+	if col < 0 {
+		return nil
+	}
+	return &rec.arch.chunks[rec.chunk].columns[col].(*column[Hierarchy]).data[rec.row]
+}
+
+// link is like node, but marks the component as changed.
+func (w *World) link(e EntityID) *Hierarchy {
+	rec, _ := w.em.get(e)
+	col := rec.arch.chunks[rec.chunk].columns[rec.arch.column(w.hierarchy)].(*column[Hierarchy])
+	col.stamp[rec.row].changed = w.tick
+	return &col.data[rec.row]
+}
+
+// pendingParent returns the parent that the entity will have once the queued changes
+// have been applied. The caller must hold World.mu.
+func (w *World) pendingParent(e EntityID) EntityID {
+	if w.reparents > 0 {
+		for i := len(w.oplog) - 1; i >= 0; i-- {
+			if op := w.oplog[i]; op.Kind == Reparent && op.Entity == e {
+				return op.Value.(EntityID)
+			}
+		}
+	}
+	if n := w.node(e); n != nil {
+		return n.Parent
+	}
+	return 0
+}
+
+// removeDescendants queues the descendants of the entity for removal, including
+// the children whose SetParent is still queued. The caller must hold World.mu.
+func (w *World) removeDescendants(e EntityID) {
+	w.pendingChildren(e, func(c EntityID, rec *entityRecord) {
+		w.queueRemoveEntity(c, rec)
+		w.removeDescendants(c)
+	})
+}
+
+// pendingChildren calls fn with the children of the entity that are not being removed,
+// including the children whose SetParent is still queued. The caller must hold World.mu.
+func (w *World) pendingChildren(e EntityID, fn func(EntityID, *entityRecord)) {
+	visit := func(c EntityID) {
+		if rec, ok := w.em.get(c); ok && !rec.removed && w.pendingParent(c) == e {
+			fn(c, rec)
+		}
+	}
+	if n := w.node(e); n != nil {
+		for c := n.FirstChild; c != 0; c = w.node(c).NextSibling {
+			visit(c)
+		}
+	}
+	if w.reparents > 0 {
+		for _, op := range w.oplog {
+			if op.Kind == Reparent && op.Value.(EntityID) == e {
+				visit(op.Entity)
+			}
+		}
+	}
+}
+
+// reparent applies SetParent.
+func (w *World) reparent(child, parent EntityID) {
+	if parent != 0 {
+		if _, ok := w.em.get(parent); !ok {
+			return
+		}
+	}
+	w.detach(child)
+	if parent == 0 {
+		return
+	}
+	// Adding the component may move the entities, so they are looked up afterwards.
+	w.ensureNode(child)
+	w.ensureNode(parent)
+	pn := w.link(parent)
+	cn := w.link(child)
+	cn.Parent = parent
+	cn.PrevSibling = pn.LastChild
+	if pn.LastChild != 0 {
+		w.link(pn.LastChild).NextSibling = child
+	} else {
+		pn.FirstChild = child
+	}
+	pn.LastChild = child
+}
+
+// detach unlinks the entity from its parent and siblings.
+func (w *World) detach(e EntityID) {
+	n := w.node(e)
+	if n == nil || n.Parent == 0 {
+		return
+	}
+	if w.node(n.Parent) != nil {
+		if n.PrevSibling != 0 {
+			w.link(n.PrevSibling).NextSibling = n.NextSibling
+		} else {
+			w.link(n.Parent).FirstChild = n.NextSibling
+		}
+		if n.NextSibling != 0 {
+			w.link(n.NextSibling).PrevSibling = n.PrevSibling
+		} else {
+			w.link(n.Parent).LastChild = n.PrevSibling
+		}
+	}
+	n = w.link(e)
+	n.Parent, n.PrevSibling, n.NextSibling = 0, 0, 0
+}
+
+// ensureNode adds the Hierarchy component to the entity right away if it does not have it.
+func (w *World) ensureNode(e EntityID) {
+	if w.node(e) != nil {
+		return
+	}
+	w.addNow(e, w.hierarchy, Hierarchy{})
+}
+
+// onHierarchyRemove detaches the entity from its parent. When the entity is removed,
+// its children are removed as well, otherwise they are left without a parent.
+func onHierarchyRemove(w *World, e EntityID, h *Hierarchy) {
+	w.detach(e)
+	rec, _ := w.em.get(e)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for c := h.FirstChild; c != 0; {
+		next := w.node(c).NextSibling
+		if rec.removed {
+			if crec, ok := w.em.get(c); ok && !crec.removed {
+				w.queueRemoveEntity(c, crec)
+			}
+		} else {
+			cn := w.link(c)
+			cn.Parent, cn.PrevSibling, cn.NextSibling = 0, 0, 0
+		}
+		c = next
+	}
+	if !rec.removed {
+		h.FirstChild, h.LastChild = 0, 0
+	}
+}
@@ -0,0 +1,79 @@
+package ecs
+
+import "fmt"
+
+// ComponentOption configures a component registered with RegisterComponent.
+type ComponentOption interface {
+	applyComponent(*componentInfo)
+}
+
+// tagOption is given to the tags registered with RegisterTag.
+type tagOption struct{}
+
+func (tagOption) applyComponent(c *componentInfo) {
+	c.tag = true
+	c.newColumn = nil
+}
+
+// Hook is called with the entity and its component.
+type Hook[T any] func(w *World, e EntityID, c *T)
+
+// Hooks are lifecycle callbacks of component T. They are passed to RegisterComponent as an option.
+//
+// OnAdd is called when the added component becomes visible at the end of the update cycle.
+// OnRemove is called when the component is about to be removed, either by RemoveComponent
+// or by RemoveEntity. OnReplace is called by SetComponent with the old value before it
+// is overwritten.
+//
+// Hooks may add and remove components and entities, these changes are applied
+// during the same flush.
+type Hooks[T any] struct {
+	OnAdd     Hook[T]
+	OnRemove  Hook[T]
+	OnReplace Hook[T]
+}
+
+func (h Hooks[T]) applyComponent(c *componentInfo) {
+	var t T
+	if TypeID(t) != c.id {
+		panic(fmt.Sprintf("hooks of type %T given to a different component", h))
+	}
+	c.hooks.onAdd = h.OnAdd.erase()
+	c.hooks.onRemove = h.OnRemove.erase()
+	c.hooks.onReplace = h.OnReplace.erase()
+}
+
+func (h Hook[T]) erase() func(*World, EntityID, columnType, int) {
+	if h == nil {
+		return nil
+	}
+	return func(w *World, e EntityID, col columnType, row int) {
+		if col == nil {
+			// Tags do not have a column.
+			h(w, e, new(T))
+			return
+		}
+		h(w, e, &col.(*column[T]).data[row])
+	}
+}
+
+// runHook calls the hook for the entity's component if the hook is set.
+func (w *World) runHook(hook func(*World, EntityID, columnType, int), e EntityID, rec *entityRecord, component int) {
+	if hook == nil {
+		return
+	}
+	col, row := w.cell(e, rec, component)
+	hook(w, e, col, row)
+}
+
+// runRemoveHooks calls OnRemove hooks of all components of the entity. When the entity
+// was moved out with MoveEntity, the hooks of the moved components are skipped.
+func (w *World) runRemoveHooks(e EntityID, rec *entityRecord, moved bool) {
+	for _, list := range [][]int{rec.arch.components, rec.arch.tags, rec.arch.sparse} {
+		for _, idx := range list {
+			if !moved || !w.movable(idx) {
+				w.runHook(w.cm.components[idx].hooks.onRemove, e, rec, idx)
+			}
+		}
+	}
+}
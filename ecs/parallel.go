@@ -0,0 +1,53 @@
+package ecs
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// ParallelForEach calls fn for every entity of the system, splitting us.Entities to
+// batches of batchSize entities that are processed by a pool of workers. It returns
+// once all of the entities have been processed.
+//
+// fn may read and modify the components of the entity it was given with GetComponent,
+// GetComponentMut and SetComponent, and queue structural changes with AddComponent,
+// RemoveComponent, NewEntity and RemoveEntity. Accessing the components of other
+// entities from fn is only safe if no worker modifies them.
+//
+// Calling this with batchSize less than 1 will panic.
+func ParallelForEach(us UpdateState, batchSize int, fn func(e EntityID)) {
+	if batchSize < 1 {
+		panic("ecs: ParallelForEach batch size must be at least 1")
+	}
+	entities := us.Entities
+	batches := (len(entities) + batchSize - 1) / batchSize
+	workers := min(batches, runtime.GOMAXPROCS(0))
+	if workers <= 1 {
+		for _, e := range entities {
+			fn(e)
+		}
+		return
+	}
+
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				batch := int(next.Add(1)) - 1
+				if batch >= batches {
+					return
+				}
+				start := batch * batchSize
+				end := min(start+batchSize, len(entities))
+				for _, e := range entities[start:end] {
+					fn(e)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
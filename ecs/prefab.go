@@ -0,0 +1,169 @@
+package ecs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Prefab is a named bundle of component values that can be instantiated with Spawn.
+//
+// Prefabs are not tied to a world, the components only need to be registered to the
+// world they are spawned in. The values are copied to every spawned entity, but like
+// with AddComponent, slices, maps and pointers inside the values are shared.
+type Prefab struct {
+	name   string
+	values []any
+}
+
+// NewPrefab returns a prefab with the component values. Values of the same type
+// replace the earlier ones.
+func NewPrefab(name string, values ...any) *Prefab {
+	p := &Prefab{name: name}
+	p.set(values)
+	return p
+}
+
+// Name returns the name of the prefab.
+func (p *Prefab) Name() string {
+	return p.name
+}
+
+func (p *Prefab) set(values []any) {
+	for _, v := range values {
+		replaced := false
+		for i, old := range p.values {
+			if TypeID(old) == TypeID(v) {
+				p.values[i] = v
+				replaced = true
+			}
+		}
+		if !replaced {
+			p.values = append(p.values, v)
+		}
+	}
+}
+
+// componentValue is a value of a component in a spawn oplog entry.
+type componentValue struct {
+	component int
+	value     any
+}
+
+// Spawn creates new entity with the components of the prefab. Overrides replace
+// the values of the prefab components of the same type, or add new components.
+//
+// Like with NewEntity and AddComponent, the components become visible at the end of
+// the update cycle. All of the components are added at once, so the entity never
+// exists without some of them and OnAdd hooks of all of the components see the whole
+// entity.
+//
+// Calling this with values of unregistered component types will panic.
+func Spawn(w *World, prefab *Prefab, overrides ...any) EntityID {
+	bundle := prefab
+	if len(overrides) > 0 {
+		bundle = &Prefab{values: append([]any(nil), prefab.values...)}
+		bundle.set(overrides)
+	}
+	values := make([]componentValue, len(bundle.values))
+	var sig Signature
+	for i, v := range bundle.values {
+		idx, ok := w.cm.lookup(TypeID(v))
+		if !ok {
+			panic(fmt.Sprintf("component %T of prefab %q is not registered", v, prefab.name))
+		}
+		values[i] = componentValue{component: idx, value: v}
+		sig.set(idx)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		panic(ErrClosed)
+	}
+	e, rec := w.em.newEntity()
+	rec.pending = sig
+	w.oplog = append(w.oplog, oplogEntry{Kind: Create, Entity: e, Value: values})
+	return e
+}
+
+// spawnValues returns the values of a Create oplog entry and whether the entity was moved
+// in with MoveEntity.
+func spawnValues(v any) ([]componentValue, bool) {
+	switch v := v.(type) {
+	case []componentValue:
+		return v, false
+	case movedValues:
+		return v, true
+	}
+	return nil, false
+}
+
+// spawn places new entity to the archetype of its spawn values. OnAdd hooks are not
+// called for the entities moved in with MoveEntity.
+func (w *World) spawn(e EntityID, rec *entityRecord, v any) {
+	values, moved := spawnValues(v)
+	dst := w.cm.root
+	if len(values) > 0 {
+		var sig Signature
+		for _, v := range values {
+			sig.set(v.component)
+		}
+		a, created := w.cm.getArchetype(sig)
+		w.addArchetype(a, created)
+		dst = a
+	}
+	rec.arch = dst
+	rec.chunk, rec.row = dst.alloc(e)
+	for _, v := range values {
+		w.setValue(e, rec, dst, v.component, v.value)
+	}
+	w.sm.addEntity(e, dst.sig)
+	if moved {
+		return
+	}
+	for _, v := range values {
+		w.runHook(w.cm.components[v.component].hooks.onAdd, e, rec, v.component)
+	}
+}
+
+// LoadPrefabs reads prefabs from JSON. The JSON object maps the names of the prefabs
+// to objects that map the names of the components to their values:
+//
+//	{
+//	    "player": {
+//	        "game.Player": {"Speed": 600},
+//	        "transform": {"Position": {"X": 200, "Y": 200}}
+//	    }
+//	}
+//
+// Components are identified by the same names as in saved worlds, see Named. Values
+// are decoded on top of the zero value, so fields can be left out.
+func LoadPrefabs(w *World, in io.Reader) (map[string]*Prefab, error) {
+	var data map[string]map[string]json.RawMessage
+	if err := json.NewDecoder(in).Decode(&data); err != nil {
+		return nil, fmt.Errorf("ecs: decoding prefabs: %w", err)
+	}
+	components := make(map[string]int, len(w.cm.components))
+	for idx, info := range w.cm.components {
+		components[info.saveName] = idx
+	}
+	prefabs := make(map[string]*Prefab, len(data))
+	for name, values := range data {
+		p := &Prefab{name: name}
+		for component, raw := range values {
+			idx, ok := components[component]
+			if !ok {
+				return nil, fmt.Errorf("%w: component %q of prefab %q is not registered", ErrUnknownType, component, name)
+			}
+			info := w.cm.components[idx]
+			v, err := info.codec.decode(raw)
+			if err != nil {
+				return nil, fmt.Errorf("ecs: decoding component %s of prefab %q: %w", info.name, name, err)
+			}
+			p.values = append(p.values, v)
+		}
+		prefabs[name] = p
+	}
+	return prefabs, nil
+}
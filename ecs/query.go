@@ -0,0 +1,453 @@
+package ecs
+
+import "iter"
+
+// QuerySource is where a query looks for its entities.
+//
+// *World queries every entity in the world. UpdateState queries only the entities
+// of the running system, reusing the archetypes the system has already matched.
+type QuerySource interface {
+	querySource() (*World, *systemEntry)
+}
+
+func (w *World) querySource() (*World, *systemEntry) {
+	return w, nil
+}
+
+func (us UpdateState) querySource() (*World, *systemEntry) {
+	return us.World, us.system
+}
+
+type query struct {
+	w          *World
+	sig        Signature
+	terms      terms
+	archetypes []*archetype
+
+	// since is the tick after which changes are reported by the change filters.
+	since   uint64
+	added   []int
+	changed []int
+	mutable []int
+	removed map[EntityID]struct{}
+	// despawned holds the removed entities matched by the Removed filter.
+	despawned []EntityID
+	plain     bool
+}
+
+// newQuery prepares query for the components. Queries run inside a system
+// also inherit the filters of the system.
+func newQuery(src QuerySource, filters []Filter, components ...int) query {
+	w, s := src.querySource()
+	q := query{w: w, archetypes: w.cm.archetypes, since: w.prevTick}
+	if s != nil {
+		q.archetypes = s.archetypes
+		q.terms = s.terms
+		q.since = s.lastRun
+	}
+	for _, f := range filters {
+		q.terms.add(f)
+	}
+	for _, c := range components {
+		if !q.terms.optional.has(c) {
+			q.sig.set(c)
+		}
+	}
+	q.added = q.terms.added.indices()
+	q.changed = q.terms.changed.indices()
+	q.mutable = q.terms.mutable.indices()
+	if !q.terms.removed.IsZero() {
+		q.removed = make(map[EntityID]struct{})
+		for _, r := range w.removed {
+			switch {
+			case r.tick <= q.since:
+			case r.despawned.IsZero():
+				if q.terms.removed.has(r.component) {
+					q.removed[r.entity] = struct{}{}
+				}
+			case q.matchesDespawned(s, r.despawned):
+				q.despawned = append(q.despawned, r.entity)
+			}
+		}
+	}
+	q.plain = q.removed == nil && len(q.added) == 0 && len(q.changed) == 0 && len(q.mutable) == 0
+	return q
+}
+
+// matchesDespawned reports whether the entity removed with the signature would have
+// been matched by the query and lost the components of the Removed filter.
+func (q *query) matchesDespawned(s *systemEntry, sig Signature) bool {
+	if s != nil && !s.matches(sig) {
+		return false
+	}
+	return sig.Contains(q.sig) && !sig.Intersects(q.terms.without) && sig.Intersects(q.terms.removed)
+}
+
+// filtered reports whether the rows need to be checked with accept.
+func (q *query) filtered() bool {
+	return !q.plain || q.w.removals.Load() > 0
+}
+
+// chunks returns all non-empty chunks of the archetypes matching the query.
+func (q *query) chunks() iter.Seq2[*archetype, *chunk] {
+	return func(yield func(*archetype, *chunk) bool) {
+		for _, a := range q.archetypes {
+			if a.len == 0 || !a.sig.Contains(q.sig) || a.sig.Intersects(q.terms.without) {
+				continue
+			}
+			for _, c := range a.chunks {
+				if !yield(a, c) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// accept reports whether the entity on the row should be yielded and marks its
+// mutable components changed if so.
+//
+// Entities are skipped when they or some of the required components are queued
+// for removal, in which case GetComponent would not return them either.
+func (q *query) accept(a *archetype, c *chunk, row int) bool {
+	e := c.entities[row]
+	if q.w.removals.Load() > 0 && q.w.queuedForRemovalAny(q.w.em.record(e.Index()), q.sig) {
+		return false
+	}
+	if q.removed != nil {
+		if _, ok := q.removed[e]; !ok {
+			return false
+		}
+	}
+	if len(q.added) > 0 && !q.newer(a, c, row, q.added, true) {
+		return false
+	}
+	if len(q.changed) > 0 && !q.newer(a, c, row, q.changed, false) {
+		return false
+	}
+	for _, comp := range q.mutable {
+		if t := q.ticks(a, c, row, comp); t != nil {
+			t.changed = q.w.tick
+		}
+	}
+	return true
+}
+
+// newer reports whether any of the components was added or changed after q.since.
+func (q *query) newer(a *archetype, c *chunk, row int, components []int, added bool) bool {
+	for _, comp := range components {
+		t := q.ticks(a, c, row, comp)
+		if t == nil {
+			continue
+		}
+		tick := t.changed
+		if added {
+			tick = t.added
+		}
+		if tick > q.since {
+			return true
+		}
+	}
+	return false
+}
+
+// ticks returns the ticks of the component on the row, or nil if the component
+// is a tag or the entity does not have it.
+func (q *query) ticks(a *archetype, c *chunk, row int, component int) *componentTicks {
+	switch col := a.column(component); {
+	case col >= 0:
+		return c.columns[col].ticks(row)
+	case col == sparseColumn:
+		if set := q.w.cm.storage(component); set != nil {
+			if col, row, ok := set.cell(c.entities[row]); ok {
+				return col.ticks(row)
+			}
+		}
+	}
+	return nil
+}
+
+// columnData returns the values of the component in the chunk. Components stored in
+// sparse sets are looked up by at instead.
+func columnData[T any](c *chunk, col int) []T {
+	if col == tagColumn {
+		// Tags are zero-size, so this does not allocate.
+		return make([]T, len(c.entities))
+	}
+	if col < 0 {
+		return nil
+	}
+	return c.columns[col].(*column[T]).data
+}
+
+// at returns pointer to the component on the row, or nil if the chunk does not
+// store the optional component or it is queued for removal.
+func at[T any](q *query, data []T, row int, e EntityID, component int) *T {
+	var v *T
+	if data != nil {
+		v = &data[row]
+	} else if set := q.w.cm.storage(component); set != nil {
+		v = set.(*sparseSet[T]).get(e)
+	}
+	if v == nil {
+		return nil
+	}
+	if q.w.removals.Load() > 0 && q.w.queuedForRemoval(q.w.em.record(e.Index()), component) {
+		return nil
+	}
+	return v
+}
+
+// Query1 returns iterator over the entities that have component A.
+//
+// Like GetComponent, the iterator skips components that were added or removed
+// during the current update cycle. Filters can be used to exclude entities, to
+// make A optional or to only match entities with changed components.
+func Query1[A any](src QuerySource, filters ...Filter) iter.Seq2[EntityID, *A] {
+	w, _ := src.querySource()
+	idxA := getComponentIdx[A](w.cm)
+	q := newQuery(src, filters, idxA)
+	return func(yield func(EntityID, *A) bool) {
+		for a, c := range q.chunks() {
+			as := columnData[A](c, a.column(idxA))
+			for i, e := range c.entities {
+				if q.filtered() && !q.accept(a, c, i) {
+					continue
+				}
+				if !yield(e, at(&q, as, i, e, idxA)) {
+					return
+				}
+			}
+		}
+		for _, e := range q.despawned {
+			if !yield(e, nil) {
+				return
+			}
+		}
+	}
+}
+
+// Row2 holds the components yielded by Query2.
+type Row2[A, B any] struct {
+	A *A
+	B *B
+}
+
+// Query2 returns iterator over the entities that have all of the components A and B.
+func Query2[A, B any](src QuerySource, filters ...Filter) iter.Seq2[EntityID, Row2[A, B]] {
+	w, _ := src.querySource()
+	idxA := getComponentIdx[A](w.cm)
+	idxB := getComponentIdx[B](w.cm)
+	q := newQuery(src, filters, idxA, idxB)
+	return func(yield func(EntityID, Row2[A, B]) bool) {
+		for a, c := range q.chunks() {
+			as := columnData[A](c, a.column(idxA))
+			bs := columnData[B](c, a.column(idxB))
+			for i, e := range c.entities {
+				if q.filtered() && !q.accept(a, c, i) {
+					continue
+				}
+				row := Row2[A, B]{
+					A: at(&q, as, i, e, idxA),
+					B: at(&q, bs, i, e, idxB),
+				}
+				if !yield(e, row) {
+					return
+				}
+			}
+		}
+		for _, e := range q.despawned {
+			if !yield(e, Row2[A, B]{}) {
+				return
+			}
+		}
+	}
+}
+
+// Row3 holds the components yielded by Query3.
+type Row3[A, B, C any] struct {
+	A *A
+	B *B
+	C *C
+}
+
+// Query3 returns iterator over the entities that have all of the components A, B and C.
+func Query3[A, B, C any](src QuerySource, filters ...Filter) iter.Seq2[EntityID, Row3[A, B, C]] {
+	w, _ := src.querySource()
+	idxA := getComponentIdx[A](w.cm)
+	idxB := getComponentIdx[B](w.cm)
+	idxC := getComponentIdx[C](w.cm)
+	q := newQuery(src, filters, idxA, idxB, idxC)
+	return func(yield func(EntityID, Row3[A, B, C]) bool) {
+		for a, c := range q.chunks() {
+			as := columnData[A](c, a.column(idxA))
+			bs := columnData[B](c, a.column(idxB))
+			cs := columnData[C](c, a.column(idxC))
+			for i, e := range c.entities {
+				if q.filtered() && !q.accept(a, c, i) {
+					continue
+				}
+				row := Row3[A, B, C]{
+					A: at(&q, as, i, e, idxA),
+					B: at(&q, bs, i, e, idxB),
+					C: at(&q, cs, i, e, idxC),
+				}
+				if !yield(e, row) {
+					return
+				}
+			}
+		}
+		for _, e := range q.despawned {
+			if !yield(e, Row3[A, B, C]{}) {
+				return
+			}
+		}
+	}
+}
+
+// Row4 holds the components yielded by Query4.
+type Row4[A, B, C, D any] struct {
+	A *A
+	B *B
+	C *C
+	D *D
+}
+
+// Query4 returns iterator over the entities that have all of the components A, B, C and D.
+func Query4[A, B, C, D any](src QuerySource, filters ...Filter) iter.Seq2[EntityID, Row4[A, B, C, D]] {
+	w, _ := src.querySource()
+	idxA := getComponentIdx[A](w.cm)
+	idxB := getComponentIdx[B](w.cm)
+	idxC := getComponentIdx[C](w.cm)
+	idxD := getComponentIdx[D](w.cm)
+	q := newQuery(src, filters, idxA, idxB, idxC, idxD)
+	return func(yield func(EntityID, Row4[A, B, C, D]) bool) {
+		for a, c := range q.chunks() {
+			as := columnData[A](c, a.column(idxA))
+			bs := columnData[B](c, a.column(idxB))
+			cs := columnData[C](c, a.column(idxC))
+			ds := columnData[D](c, a.column(idxD))
+			for i, e := range c.entities {
+				if q.filtered() && !q.accept(a, c, i) {
+					continue
+				}
+				row := Row4[A, B, C, D]{
+					A: at(&q, as, i, e, idxA),
+					B: at(&q, bs, i, e, idxB),
+					C: at(&q, cs, i, e, idxC),
+					D: at(&q, ds, i, e, idxD),
+				}
+				if !yield(e, row) {
+					return
+				}
+			}
+		}
+		for _, e := range q.despawned {
+			if !yield(e, Row4[A, B, C, D]{}) {
+				return
+			}
+		}
+	}
+}
+
+// Row5 holds the components yielded by Query5.
+type Row5[A, B, C, D, E any] struct {
+	A *A
+	B *B
+	C *C
+	D *D
+	E *E
+}
+
+// Query5 returns iterator over the entities that have all of the components A, B, C, D and E.
+func Query5[A, B, C, D, E any](src QuerySource, filters ...Filter) iter.Seq2[EntityID, Row5[A, B, C, D, E]] {
+	w, _ := src.querySource()
+	idxA := getComponentIdx[A](w.cm)
+	idxB := getComponentIdx[B](w.cm)
+	idxC := getComponentIdx[C](w.cm)
+	idxD := getComponentIdx[D](w.cm)
+	idxE := getComponentIdx[E](w.cm)
+	q := newQuery(src, filters, idxA, idxB, idxC, idxD, idxE)
+	return func(yield func(EntityID, Row5[A, B, C, D, E]) bool) {
+		for a, c := range q.chunks() {
+			as := columnData[A](c, a.column(idxA))
+			bs := columnData[B](c, a.column(idxB))
+			cs := columnData[C](c, a.column(idxC))
+			ds := columnData[D](c, a.column(idxD))
+			es := columnData[E](c, a.column(idxE))
+			for i, e := range c.entities {
+				if q.filtered() && !q.accept(a, c, i) {
+					continue
+				}
+				row := Row5[A, B, C, D, E]{
+					A: at(&q, as, i, e, idxA),
+					B: at(&q, bs, i, e, idxB),
+					C: at(&q, cs, i, e, idxC),
+					D: at(&q, ds, i, e, idxD),
+					E: at(&q, es, i, e, idxE),
+				}
+				if !yield(e, row) {
+					return
+				}
+			}
+		}
+		for _, e := range q.despawned {
+			if !yield(e, Row5[A, B, C, D, E]{}) {
+				return
+			}
+		}
+	}
+}
+
+// Row6 holds the components yielded by Query6.
+type Row6[A, B, C, D, E, F any] struct {
+	A *A
+	B *B
+	C *C
+	D *D
+	E *E
+	F *F
+}
+
+// Query6 returns iterator over the entities that have all of the components A, B, C, D, E and F.
+func Query6[A, B, C, D, E, F any](src QuerySource, filters ...Filter) iter.Seq2[EntityID, Row6[A, B, C, D, E, F]] {
+	w, _ := src.querySource()
+	idxA := getComponentIdx[A](w.cm)
+	idxB := getComponentIdx[B](w.cm)
+	idxC := getComponentIdx[C](w.cm)
+	idxD := getComponentIdx[D](w.cm)
+	idxE := getComponentIdx[E](w.cm)
+	idxF := getComponentIdx[F](w.cm)
+	q := newQuery(src, filters, idxA, idxB, idxC, idxD, idxE, idxF)
+	return func(yield func(EntityID, Row6[A, B, C, D, E, F]) bool) {
+		for a, c := range q.chunks() {
+			as := columnData[A](c, a.column(idxA))
+			bs := columnData[B](c, a.column(idxB))
+			cs := columnData[C](c, a.column(idxC))
+			ds := columnData[D](c, a.column(idxD))
+			es := columnData[E](c, a.column(idxE))
+			fs := columnData[F](c, a.column(idxF))
+			for i, e := range c.entities {
+				if q.filtered() && !q.accept(a, c, i) {
+					continue
+				}
+				row := Row6[A, B, C, D, E, F]{
+					A: at(&q, as, i, e, idxA),
+					B: at(&q, bs, i, e, idxB),
+					C: at(&q, cs, i, e, idxC),
+					D: at(&q, ds, i, e, idxD),
+					E: at(&q, es, i, e, idxE),
+					F: at(&q, fs, i, e, idxF),
+				}
+				if !yield(e, row) {
+					return
+				}
+			}
+		}
+		for _, e := range q.despawned {
+			if !yield(e, Row6[A, B, C, D, E, F]{}) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package ecs
+
+import "fmt"
+
+// Registry holds the registered component types. Every world has a registry, and worlds
+// created with the same registry share their component registrations, so that entities
+// can be moved between them with MoveEntity.
+//
+// Components registered to any of the worlds are registered to all of them. Registering
+// components while other worlds sharing the registry are running is not safe.
+type Registry struct {
+	components     []componentInfo
+	componentToIdx []int
+}
+
+// NewRegistry creates new empty registry.
+func NewRegistry() *Registry {
+	return &Registry{components: make([]componentInfo, 0, 64)}
+}
+
+// WorldOption configures a world created with New.
+type WorldOption interface {
+	applyWorld(*World)
+}
+
+// registryOption is returned by WithRegistry.
+type registryOption struct {
+	r *Registry
+}
+
+func (o registryOption) applyWorld(w *World) {
+	w.cm = newComponentManager(o.r)
+}
+
+// WithRegistry creates the world with the given registry instead of a new one.
+func WithRegistry(r *Registry) WorldOption {
+	return registryOption{r: r}
+}
+
+// Registry returns the registry of the world.
+func (w *World) Registry() *Registry {
+	return w.cm.Registry
+}
+
+// movedEntity is an entity collected by MoveEntity.
+type movedEntity struct {
+	entity EntityID
+	parent EntityID
+	values []componentValue
+}
+
+// movedValues are the spawn values of an entity moved in with MoveEntity.
+type movedValues []componentValue
+
+// movedOut is the value of the Destroy oplog entry of an entity moved out with MoveEntity.
+type movedOut struct{}
+
+// MoveEntity moves the entity from src to dst and returns its ID in dst. The worlds must
+// share a registry, see WithRegistry.
+//
+// The components of the entity are copied to dst right away, including the changes that
+// are still queued for the entity, and the entity is removed from src. In dst the entity
+// is created like with Spawn, so it becomes visible at the end of the update cycle.
+// Descendants of the entity move along with it and keep their place in the hierarchy.
+// Relations are not moved, in src they are handled as if the entity was removed.
+//
+// The moved components keep their values, so their OnRemove hooks are not called in src
+// and their OnAdd hooks are not called in dst. Only the hooks of the hierarchy and the
+// relations run, as they are not moved.
+//
+// Calling this on non-existent entity or with worlds that do not share a registry will panic.
+func MoveEntity(src, dst *World, e EntityID) EntityID {
+	if src == dst {
+		panic(fmt.Sprintf("entity %v is moved to the world it is in", e))
+	}
+	if src.cm.Registry != dst.cm.Registry {
+		panic("worlds do not share a registry")
+	}
+
+	src.mu.Lock()
+	moved := []movedEntity{{entity: e, values: src.pendingValues(e, src.em.mustGetPending(e))}}
+	seen := map[EntityID]bool{e: true}
+	for i := 0; i < len(moved); i++ {
+		parent := moved[i].entity
+		src.pendingChildren(parent, func(c EntityID, rec *entityRecord) {
+			if !seen[c] {
+				seen[c] = true
+				moved = append(moved, movedEntity{entity: c, parent: parent, values: src.pendingValues(c, rec)})
+			}
+		})
+	}
+	for _, m := range moved {
+		src.queueRemoveEntity(m.entity, src.em.mustGetPending(m.entity))
+		src.oplog[len(src.oplog)-1].Value = movedOut{}
+	}
+	src.mu.Unlock()
+
+	dst.mu.Lock()
+	defer dst.mu.Unlock()
+	ids := make(map[EntityID]EntityID, len(moved))
+	for _, m := range moved {
+		id, rec := dst.em.newEntity()
+		for _, v := range m.values {
+			rec.pending.set(v.component)
+		}
+		dst.oplog = append(dst.oplog, oplogEntry{Kind: Create, Entity: id, Value: movedValues(m.values)})
+		if m.parent != 0 {
+			dst.reparents++
+			dst.oplog = append(dst.oplog, oplogEntry{Kind: Reparent, Entity: id, Value: ids[m.parent]})
+		}
+		ids[m.entity] = id
+	}
+	return ids[e]
+}
+
+// pendingValues returns the components that the entity has once the oplog has been
+// applied. Hierarchy and relations are left out, as they refer to the entities of this
+// world. The caller must hold World.mu.
+func (w *World) pendingValues(e EntityID, rec *entityRecord) []componentValue {
+	values := make([]any, len(w.cm.components))
+	if rec.arch != nil {
+		for idx := range values {
+			if rec.arch.sig.has(idx) {
+				if col, row := w.cell(e, rec, idx); col != nil {
+					values[idx] = col.get(row)
+				}
+			}
+		}
+	}
+	for _, op := range w.oplog {
+		if op.Entity != e {
+			continue
+		}
+		switch op.Kind {
+		case Create:
+			spawned, _ := spawnValues(op.Value)
+			for _, v := range spawned {
+				values[v.component] = v.value
+			}
+		case Add:
+			values[op.Component] = op.Value
+		}
+	}
+	var bundle []componentValue
+	for idx, v := range values {
+		if rec.pending.has(idx) && w.movable(idx) {
+			bundle = append(bundle, componentValue{component: idx, value: v})
+		}
+	}
+	return bundle
+}
+
+// movable reports whether the component is moved by MoveEntity.
+func (w *World) movable(component int) bool {
+	return component != w.hierarchy && w.cm.components[component].newRelation == nil
+}
@@ -0,0 +1,48 @@
+package ecs
+
+import "testing"
+
+// texture is loaded by its OnAdd hook and released by its OnRemove hook.
+type texture struct {
+	loaded *bool
+}
+
+func TestMoveEntityKeepsResources(t *testing.T) {
+	loads := 0
+	registry := NewRegistry()
+	src := New(WithRegistry(registry))
+	dst := New(WithRegistry(registry))
+	RegisterComponent[texture](src, Hooks[texture]{
+		OnAdd: func(w *World, e EntityID, c *texture) {
+			*c.loaded = true
+			loads++
+		},
+		OnRemove: func(w *World, e EntityID, c *texture) { *c.loaded = false },
+	})
+	var parentTexture, childTexture bool
+	parent := NewEntity(src)
+	child := NewEntity(src)
+	AddComponent(src, parent, texture{&parentTexture})
+	AddComponent(src, child, texture{&childTexture})
+	SetParent(src, child, parent)
+	src.Init()
+	dst.Init()
+
+	moved := MoveEntity(src, dst, parent)
+	src.RunUpdate(0)
+	dst.RunUpdate(0)
+	if IsAlive(src, child) {
+		t.Error("child was not moved out of src")
+	}
+	if !parentTexture || !childTexture {
+		t.Error("texture of a moved entity was released")
+	}
+	if loads != 2 {
+		t.Errorf("textures were loaded %d times, want 2", loads)
+	}
+	RemoveEntity(dst, moved)
+	dst.RunUpdate(0)
+	if parentTexture || childTexture {
+		t.Error("texture was not released when the moved entities were removed")
+	}
+}
@@ -0,0 +1,329 @@
+package ecs
+
+import (
+	"fmt"
+	"iter"
+	"slices"
+	"sync/atomic"
+)
+
+// RelationPolicy decides what happens to relations when their target entity is removed.
+type RelationPolicy int
+
+const (
+	// DropRelation removes the relations to the removed target.
+	DropRelation RelationPolicy = iota
+	// RemoveSource removes the entities that have a relation to the removed target.
+	RemoveSource
+)
+
+// Pair is a relation of kind R to the target entity along with the data of the relation.
+type Pair[R any] struct {
+	Target EntityID
+	Data   R
+}
+
+// Relations is the component that holds the relations of kind R of an entity. It is added
+// by AddRelation and can be used in signatures to match the entities that have relations of
+// kind R, although it may be left empty after the relations have been removed.
+//
+// Relations should only be changed with AddRelation and RemoveRelation.
+type Relations[R any] []Pair[R]
+
+// relationType is the type erased relationIndex.
+type relationType interface {
+	// targetRemoved applies the policy of the relation to the sources of the removed entity.
+	targetRemoved(w *World, e EntityID)
+	invalidate()
+	// refresh rebuilds the index if it has been invalidated.
+	refresh(w *World)
+}
+
+// relationIndex maps the targets of relation R to their sources.
+type relationIndex[R any] struct {
+	component int
+	policy    RelationPolicy
+	sources   map[EntityID][]EntityID
+	// dirty is set when the Relations component has been replaced without AddRelation.
+	dirty atomic.Bool
+}
+
+// relationOp is queued by AddRelation and RemoveRelation.
+type relationOp[R any] struct {
+	target EntityID
+	data   R
+	remove bool
+}
+
+// relationChange is the type erased relationOp.
+type relationChange interface {
+	apply(w *World, source EntityID)
+}
+
+// RegisterRelation registers relation of kind R. Entities can then have relations of kind R
+// to any number of target entities, each carrying a value of R as its data.
+//
+// The policy decides what happens to the relations when their target is removed. Options
+// such as Named are passed to the registration of the Relations component.
+func RegisterRelation[R any](w *World, policy RelationPolicy, opts ...ComponentOption) {
+	hooks := Hooks[Relations[R]]{
+		OnAdd: func(w *World, e EntityID, rel *Relations[R]) {
+			// New index is built with the added relations already in it.
+			if r, created := relationOf[R](w); !created {
+				r.onAdd(e, rel)
+			}
+		},
+		OnRemove: func(w *World, e EntityID, rel *Relations[R]) {
+			r, _ := relationOf[R](w)
+			r.onRemove(e, rel)
+		},
+		OnReplace: func(w *World, e EntityID, rel *Relations[R]) {
+			// The new value is not known yet, so the index is rebuilt at the end of the update cycle.
+			if r := findRelation[R](w); r != nil {
+				r.invalidate()
+			}
+		},
+	}
+	RegisterComponent[Relations[R]](w, append(slices.Clip(opts), hooks, relationOption[R]{policy})...)
+}
+
+// relationOption marks the Relations component registered by RegisterRelation.
+type relationOption[R any] struct {
+	policy RelationPolicy
+}
+
+func (o relationOption[R]) applyComponent(c *componentInfo) {
+	c.newRelation = func(idx int) relationType {
+		return &relationIndex[R]{component: idx, policy: o.policy, sources: make(map[EntityID][]EntityID)}
+	}
+}
+
+// AddRelation adds relation of kind R from source to target. If the source already has
+// the relation to the target, its data is replaced.
+//
+// Like structural changes, the relation is added at the end of the update cycle.
+// Calling this on non-existent entities or with unregistered relation will panic.
+func AddRelation[R any](w *World, source, target EntityID, data R) {
+	idx := relationComponent[R](w)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.em.mustGetPending(source)
+	w.em.mustGetPending(target)
+	w.oplog = append(w.oplog, oplogEntry{Kind: Relate, Entity: source, Component: idx, Value: relationOp[R]{target: target, data: data}})
+}
+
+// RemoveRelation removes relation of kind R from source to target at the end of the update
+// cycle. Nothing is done if the source does not have the relation.
+//
+// Calling this on non-existent source entity will panic.
+func RemoveRelation[R any](w *World, source, target EntityID) {
+	idx := relationComponent[R](w)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.em.mustGetPending(source)
+	w.oplog = append(w.oplog, oplogEntry{Kind: Relate, Entity: source, Component: idx, Value: relationOp[R]{target: target, remove: true}})
+}
+
+// GetRelation returns the data of relation R from source to target, or false if the
+// source does not have the relation.
+func GetRelation[R any](w *World, source, target EntityID) (R, bool) {
+	for t, data := range Targets[R](w, source) {
+		if t == target {
+			return data, true
+		}
+	}
+	var zero R
+	return zero, false
+}
+
+// Targets iterates over the targets of the relations of kind R that the source has,
+// in the order that the relations were added.
+func Targets[R any](w *World, source EntityID) iter.Seq2[EntityID, R] {
+	return func(yield func(EntityID, R) bool) {
+		rel := getComponent[Relations[R]](w, source)
+		if rel == nil {
+			return
+		}
+		for _, p := range *rel {
+			if IsAlive(w, p.Target) && !yield(p.Target, p.Data) {
+				return
+			}
+		}
+	}
+}
+
+// Sources iterates over the entities that have relation of kind R to the target, along
+// with the data of their relations.
+func Sources[R any](w *World, target EntityID) iter.Seq2[EntityID, R] {
+	r := findRelation[R](w)
+	return func(yield func(EntityID, R) bool) {
+		if r == nil {
+			return
+		}
+		for _, source := range r.sources[target] {
+			rel := getComponent[Relations[R]](w, source)
+			if rel == nil {
+				continue
+			}
+			if i := r.find(*rel, target); i >= 0 && !yield(source, (*rel)[i].Data) {
+				return
+			}
+		}
+	}
+}
+
+// relationComponent returns the index of the Relations component of relation R.
+func relationComponent[R any](w *World) int {
+	idx, ok := w.cm.lookup(TypeID(Relations[R]{}))
+	if !ok || w.cm.components[idx].newRelation == nil {
+		var r R
+		panic(fmt.Errorf("%w: relation %T", ErrNotRegistered, r))
+	}
+	return idx
+}
+
+// findRelation returns the index of relation R in the world, or nil if the world
+// does not have any relations of kind R yet.
+func findRelation[R any](w *World) *relationIndex[R] {
+	relationComponent[R](w)
+	for _, r := range w.relations {
+		if r, ok := r.(*relationIndex[R]); ok {
+			return r
+		}
+	}
+	return nil
+}
+
+// relationOf is like findRelation, but creates the index if the world does not have
+// it. Worlds that share a registry create their indices when the first relation is
+// added while the oplog is applied, so the creation does not race with the systems.
+func relationOf[R any](w *World) (*relationIndex[R], bool) {
+	if r := findRelation[R](w); r != nil {
+		return r, false
+	}
+	idx := relationComponent[R](w)
+	r := w.cm.components[idx].newRelation(idx).(*relationIndex[R])
+	r.invalidate()
+	r.refresh(w)
+	w.relations = append(w.relations, r)
+	return r, true
+}
+
+func (op relationOp[R]) apply(w *World, source EntityID) {
+	r, _ := relationOf[R](w)
+	if op.remove {
+		r.unindex(op.target, source)
+		r.drop(w, source, op.target)
+		return
+	}
+	if _, ok := w.em.get(op.target); !ok {
+		return
+	}
+	col, row := r.column(w, source)
+	if col == nil {
+		// The OnAdd hook indexes the relation.
+		w.addNow(source, r.component, Relations[R]{{Target: op.target, Data: op.data}})
+		return
+	}
+	// Relations are copied on write, so that snapshots do not share them with the world.
+	pairs := slices.Clone(col.data[row])
+	if i := r.find(pairs, op.target); i >= 0 {
+		pairs[i].Data = op.data
+	} else {
+		pairs = append(pairs, Pair[R]{Target: op.target, Data: op.data})
+		r.sources[op.target] = append(r.sources[op.target], source)
+	}
+	col.data[row] = pairs
+	col.stamp[row].changed = w.tick
+}
+
+// column returns the column and the row of the Relations component of the source, or
+// nil if the source does not have the component.
+func (r *relationIndex[R]) column(w *World, source EntityID) (*column[Relations[R]], int) {
+	rec, ok := w.em.get(source)
+	if !ok || rec.arch == nil || !rec.arch.sig.has(r.component) {
+		return nil, 0
+	}
+	col, row := w.cell(source, rec, r.component)
+	return col.(*column[Relations[R]]), row
+}
+
+func (r *relationIndex[R]) find(pairs Relations[R], target EntityID) int {
+	return slices.IndexFunc(pairs, func(p Pair[R]) bool { return p.Target == target })
+}
+
+// drop removes the relation from source to target without updating the index.
+func (r *relationIndex[R]) drop(w *World, source, target EntityID) {
+	col, row := r.column(w, source)
+	if col == nil {
+		return
+	}
+	i := r.find(col.data[row], target)
+	if i < 0 {
+		return
+	}
+	col.data[row] = slices.Delete(slices.Clone(col.data[row]), i, i+1)
+	col.stamp[row].changed = w.tick
+}
+
+func (r *relationIndex[R]) unindex(target, source EntityID) {
+	sources := r.sources[target]
+	if i := slices.Index(sources, source); i >= 0 {
+		sources = slices.Delete(sources, i, i+1)
+	}
+	if len(sources) == 0 {
+		delete(r.sources, target)
+	} else {
+		r.sources[target] = sources
+	}
+}
+
+func (r *relationIndex[R]) targetRemoved(w *World, e EntityID) {
+	sources, ok := r.sources[e]
+	if !ok {
+		return
+	}
+	delete(r.sources, e)
+	for _, source := range sources {
+		switch r.policy {
+		case DropRelation:
+			r.drop(w, source, e)
+		case RemoveSource:
+			w.mu.Lock()
+			if rec, ok := w.em.get(source); ok && !rec.removed {
+				w.queueRemoveEntity(source, rec)
+				w.removeDescendants(source)
+			}
+			w.mu.Unlock()
+		}
+	}
+}
+
+func (r *relationIndex[R]) invalidate() {
+	r.dirty.Store(true)
+}
+
+func (r *relationIndex[R]) refresh(w *World) {
+	if !r.dirty.Load() {
+		return
+	}
+	r.dirty.Store(false)
+	clear(r.sources)
+	eachValue(w, r.component, func(source EntityID, rel *Relations[R]) {
+		for _, p := range *rel {
+			r.sources[p.Target] = append(r.sources[p.Target], source)
+		}
+	})
+}
+
+func (r *relationIndex[R]) onAdd(e EntityID, rel *Relations[R]) {
+	for _, p := range *rel {
+		r.sources[p.Target] = append(r.sources[p.Target], e)
+	}
+}
+
+func (r *relationIndex[R]) onRemove(e EntityID, rel *Relations[R]) {
+	for _, p := range *rel {
+		r.unindex(p.Target, e)
+	}
+}
@@ -0,0 +1,306 @@
+package ecs
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Stage groups systems that run together. Stages run in the order they are declared,
+// systems in the same stage run in registration order unless ordered with Before and After.
+type Stage int
+
+const (
+	PreUpdate Stage = iota
+	// FixedUpdate systems run at the rate set with World.SetFixedTimestep.
+	FixedUpdate
+	Update
+	PostUpdate
+	Render
+	stageCount
+)
+
+func (s Stage) String() string {
+	switch s {
+	case PreUpdate:
+		return "PreUpdate"
+	case FixedUpdate:
+		return "FixedUpdate"
+	case Update:
+		return "Update"
+	case PostUpdate:
+		return "PostUpdate"
+	case Render:
+		return "Render"
+	}
+	return fmt.Sprintf("Stage(%d)", int(s))
+}
+
+// ErrScheduleCycle is returned when Before and After constraints of the systems form a cycle.
+var ErrScheduleCycle = errors.New("ecs: system ordering constraints form a cycle")
+
+// ErrScheduleStage is returned when Before or After constraint contradicts the stage order.
+var ErrScheduleStage = errors.New("ecs: system ordering constraint crosses stages")
+
+type stageOption Stage
+
+func (o stageOption) applySystem(se *systemEntry) {
+	se.stage = Stage(o)
+}
+
+// InStage sets the stage of the system. Systems are in Update stage by default.
+func InStage(s Stage) SystemOption {
+	if s < 0 || s >= stageCount {
+		panic(fmt.Sprintf("unknown stage %v", s))
+	}
+	return stageOption(s)
+}
+
+type orderOption struct {
+	system uint64
+	before bool
+}
+
+func (o orderOption) applySystem(se *systemEntry) {
+	if o.before {
+		se.before = append(se.before, o.system)
+	} else {
+		se.after = append(se.after, o.system)
+	}
+}
+
+// Before makes the system run before the system of type T.
+//
+// Constraints on systems that are not registered are ignored.
+func Before[T SystemType]() SystemOption {
+	var t T
+	return orderOption{system: TypeID(t), before: true}
+}
+
+// After makes the system run after the system of type T.
+//
+// Constraints on systems that are not registered are ignored.
+func After[T SystemType]() SystemOption {
+	var t T
+	return orderOption{system: TypeID(t)}
+}
+
+// BuildSchedule resolves the order the systems are run in.
+//
+// The schedule is built automatically by Init and RunUpdate after systems have been
+// registered, which panic if the constraints can not be satisfied. BuildSchedule can be
+// called to get the error instead.
+func (w *World) BuildSchedule() error {
+	return w.sm.buildSchedule(w.cm)
+}
+
+func (w *World) mustBuildSchedule() {
+	if !w.sm.dirty {
+		return
+	}
+	if err := w.sm.buildSchedule(w.cm); err != nil {
+		panic(err)
+	}
+}
+
+// SetParallel enables running systems of the same stage in parallel.
+//
+// Systems run in parallel only if they have declared their access with Reads, Writes,
+// ReadsSingleton or WritesSingleton, the accesses do not conflict and the systems are
+// not ordered with Before or After. Structural changes and events from the systems are
+// queued as usual and applied at the end of the update cycle.
+func (w *World) SetParallel(enabled bool) {
+	w.sm.parallel = enabled
+	w.sm.dirty = true
+}
+
+// DescribeSchedule returns human readable description of the schedule. Each line lists
+// systems that run in parallel, followed by the reason why the first of them could
+// not run with the previous batch.
+func (w *World) DescribeSchedule() string {
+	w.mustBuildSchedule()
+	var b strings.Builder
+	stage := Stage(-1)
+	for i, batch := range w.sm.batches {
+		if batch[0].stage != stage {
+			stage = batch[0].stage
+			fmt.Fprintf(&b, "%v:\n", stage)
+		}
+		names := make([]string, len(batch))
+		for j, s := range batch {
+			names[j] = systemName(s)
+		}
+		fmt.Fprintf(&b, "  %d: %s", i+1, strings.Join(names, ", "))
+		if reason := batch[0].serialized; reason != "" {
+			fmt.Fprintf(&b, " (%s)", reason)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func systemName(se *systemEntry) string {
+	return fmt.Sprintf("%T", se.system)
+}
+
+// buildSchedule sorts the systems of each stage topologically. Among the systems
+// that are free to run, the one registered first is picked.
+//
+// The sorted systems are then split to batches. In parallel mode consecutive systems
+// that do not conflict share a batch.
+func (sm *systemManager) buildSchedule(cm *componentManager) error {
+	edges := make(map[*systemEntry][]*systemEntry, len(sm.systems))
+	indegree := make(map[*systemEntry]int, len(sm.systems))
+	addEdge := func(from, to *systemEntry) error {
+		if from.stage != to.stage {
+			if from.stage > to.stage {
+				return fmt.Errorf("%w: %s in stage %v can not run before %s in stage %v",
+					ErrScheduleStage, systemName(from), from.stage, systemName(to), to.stage)
+			}
+			return nil
+		}
+		edges[from] = append(edges[from], to)
+		indegree[to]++
+		return nil
+	}
+	for _, s := range sm.systems {
+		for _, id := range s.before {
+			for _, other := range sm.systemsOf(id) {
+				if err := addEdge(s, other); err != nil {
+					return err
+				}
+			}
+		}
+		for _, id := range s.after {
+			for _, other := range sm.systemsOf(id) {
+				if err := addEdge(other, s); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	schedule := make([]*systemEntry, 0, len(sm.systems))
+	for stage := range stageCount {
+		var pending []*systemEntry
+		for _, s := range sm.systems {
+			if s.stage == stage {
+				pending = append(pending, s)
+			}
+		}
+		for len(pending) > 0 {
+			next := -1
+			for i, s := range pending {
+				if indegree[s] == 0 {
+					next = i
+					break
+				}
+			}
+			if next < 0 {
+				return fmt.Errorf("%w in stage %v: %s", ErrScheduleCycle, stage, findCycle(pending, edges))
+			}
+			s := pending[next]
+			pending = append(pending[:next], pending[next+1:]...)
+			schedule = append(schedule, s)
+			for _, to := range edges[s] {
+				indegree[to]--
+			}
+		}
+	}
+	sm.schedule = schedule
+	sm.batches = sm.batches[:0]
+	var batch []*systemEntry
+	for _, s := range schedule {
+		s.serialized = ""
+		if len(batch) > 0 {
+			if sm.parallel {
+				s.serialized = batchConflict(cm, batch, s, edges)
+			}
+			if !sm.parallel || batch[0].stage != s.stage || s.serialized != "" {
+				sm.batches = append(sm.batches, batch)
+				batch = nil
+			}
+		}
+		batch = append(batch, s)
+	}
+	if len(batch) > 0 {
+		sm.batches = append(sm.batches, batch)
+	}
+	sm.dirty = false
+	return nil
+}
+
+// batchConflict describes why s can not be run in parallel with the batch.
+func batchConflict(cm *componentManager, batch []*systemEntry, s *systemEntry, edges map[*systemEntry][]*systemEntry) string {
+	for _, other := range batch {
+		if other.stage != s.stage {
+			return ""
+		}
+		if slices.Contains(edges[other], s) {
+			return fmt.Sprintf("%s is ordered before %s", systemName(other), systemName(s))
+		}
+		if reason := conflict(cm, other, s); reason != "" {
+			return reason
+		}
+	}
+	return ""
+}
+
+// systemsOf returns the registered systems of the type.
+func (sm *systemManager) systemsOf(id uint64) []*systemEntry {
+	return sm.byType[id]
+}
+
+// findCycle describes a cycle among the systems that could not be scheduled.
+func findCycle(pending []*systemEntry, edges map[*systemEntry][]*systemEntry) string {
+	remaining := make(map[*systemEntry]bool, len(pending))
+	for _, s := range pending {
+		remaining[s] = true
+	}
+	var path []*systemEntry
+	onPath := make(map[*systemEntry]int)
+	var visit func(s *systemEntry) []*systemEntry
+	visit = func(s *systemEntry) []*systemEntry {
+		if i, ok := onPath[s]; ok {
+			return append(path[i:], s)
+		}
+		onPath[s] = len(path)
+		path = append(path, s)
+		for _, to := range edges[s] {
+			if !remaining[to] {
+				continue
+			}
+			if cycle := visit(to); cycle != nil {
+				return cycle
+			}
+		}
+		path = path[:len(path)-1]
+		delete(onPath, s)
+		remaining[s] = false
+		return nil
+	}
+	for _, s := range pending {
+		if !remaining[s] {
+			continue
+		}
+		if cycle := visit(s); cycle != nil {
+			names := make([]string, len(cycle))
+			for i, c := range cycle {
+				names[i] = systemName(c)
+			}
+			return strings.Join(names, " -> ")
+		}
+	}
+	return ""
+}
+
+// stageStart returns the index of the first batch in the stage or in the stages after it.
+func (sm *systemManager) stageStart(stage Stage) int {
+	for i, batch := range sm.batches {
+		if batch[0].stage >= stage {
+			return i
+		}
+	}
+	return len(sm.batches)
+}
@@ -0,0 +1,315 @@
+package ecs
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+)
+
+// ErrWorldNotEmpty is returned when a saved world is loaded to a world that already has entities.
+var ErrWorldNotEmpty = errors.New("ecs: world is not empty")
+
+// ErrUnknownType is returned when a saved world has a component or a singleton that
+// has not been registered with the same name.
+var ErrUnknownType = errors.New("ecs: unknown type in saved world")
+
+// ErrTypeMismatch is returned when the saved values of a component or a singleton do
+// not match the registered type.
+var ErrTypeMismatch = errors.New("ecs: saved value does not match registered type")
+
+// nameOption sets the name that a component or a singleton is saved with.
+type nameOption string
+
+func (o nameOption) applyComponent(c *componentInfo) {
+	c.saveName = string(o)
+}
+
+func (o nameOption) applySingleton(s *singletonInfo) {
+	s.saveName = string(o)
+}
+
+// Named sets the name that identifies the type in saved worlds.
+//
+// Components are saved with their package path and type name by default, Named can be
+// used to keep the saved worlds loadable when the type is moved or renamed. Singletons
+// are saved only if they are registered with a name.
+func Named(name string) interface {
+	ComponentOption
+	SingletonOption
+} {
+	return nameOption(name)
+}
+
+// typeName returns the default name of T in saved worlds.
+func typeName[T any]() string {
+	t := reflect.TypeFor[T]()
+	if t.Name() == "" || t.PkgPath() == "" {
+		return t.String()
+	}
+	return t.PkgPath() + "." + t.Name()
+}
+
+// codec encodes the saved values either as JSON or as gob.
+type codec interface {
+	marshal(v any) ([]byte, error)
+	unmarshal(data []byte, v any) error
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) marshal(v any) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+
+type gobCodec struct{}
+
+func (gobCodec) marshal(v any) ([]byte, error) {
+	var b bytes.Buffer
+	err := gob.NewEncoder(&b).Encode(v)
+	return b.Bytes(), err
+}
+
+func (gobCodec) unmarshal(data []byte, v any) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+// savedWorld is the saved form of a world. Components are stored column wise, so
+// that each column is encoded as a single slice of values.
+type savedWorld struct {
+	Entities   []EntityID       `json:"entities"`
+	Free       []EntityID       `json:"free,omitempty"`
+	Components []savedColumn    `json:"components"`
+	Singletons []savedSingleton `json:"singletons,omitempty"`
+}
+
+type savedColumn struct {
+	Name     string          `json:"name"`
+	Entities []EntityID      `json:"entities"`
+	Values   json.RawMessage `json:"values"`
+}
+
+type savedSingleton struct {
+	Name  string          `json:"name"`
+	Value json.RawMessage `json:"value"`
+}
+
+// componentCodec saves and loads the values of a component type.
+type componentCodec struct {
+	save func(w *World, idx int, c codec) ([]EntityID, []byte, error)
+	load func(data []byte, c codec) ([]any, error)
+	// decode decodes a single JSON value.
+	decode func(data []byte) (any, error)
+}
+
+func newComponentCodec[T any]() componentCodec {
+	return componentCodec{
+		save: func(w *World, idx int, c codec) ([]EntityID, []byte, error) {
+			var entities []EntityID
+			var values []T
+			eachValue(w, idx, func(e EntityID, v *T) {
+				entities = append(entities, e)
+				values = append(values, *v)
+			})
+			if len(entities) == 0 {
+				return nil, nil, nil
+			}
+			data, err := c.marshal(values)
+			return entities, data, err
+		},
+		load: func(data []byte, c codec) ([]any, error) {
+			var values []T
+			if err := c.unmarshal(data, &values); err != nil {
+				return nil, err
+			}
+			boxed := make([]any, len(values))
+			for i, v := range values {
+				boxed[i] = v
+			}
+			return boxed, nil
+		},
+		decode: func(data []byte) (any, error) {
+			var v T
+			err := json.Unmarshal(data, &v)
+			return v, err
+		},
+	}
+}
+
+// SaveJSON writes the entities, their components and the named singletons of the
+// world as indented JSON. Changes that are still queued are not saved.
+//
+// Components that no entity has are left out. The hierarchy made with SetParent is
+// saved as the "ecs.Hierarchy" component, so it is restored by LoadJSON as well.
+//
+// SaveJSON should not be called during an update cycle.
+func (w *World) SaveJSON(out io.Writer) error {
+	saved, err := w.save(jsonCodec{})
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(saved)
+}
+
+// SaveBinary is like SaveJSON, but writes compact binary encoding made with encoding/gob.
+func (w *World) SaveBinary(out io.Writer) error {
+	saved, err := w.save(gobCodec{})
+	if err != nil {
+		return err
+	}
+	return gob.NewEncoder(out).Encode(saved)
+}
+
+// LoadJSON loads a world saved with SaveJSON. Components and named singletons must be
+// registered before loading, and the world must not have any entities.
+//
+// Components and singletons that are registered but missing from the saved world are
+// left empty and keep their current values respectively, so that registering new types
+// does not break the old saves. Types in the saved world that are not registered
+// cause ErrUnknownType, and values that do not decode to the registered type cause
+// ErrTypeMismatch.
+//
+// Entities keep their EntityIDs, so references between the entities stay valid. The
+// loaded entities are created like with Spawn, which runs the OnAdd hooks.
+// Values of the singletons are overwritten in place.
+func (w *World) LoadJSON(in io.Reader) error {
+	var saved savedWorld
+	if err := json.NewDecoder(in).Decode(&saved); err != nil {
+		return fmt.Errorf("ecs: decoding saved world: %w", err)
+	}
+	return w.load(&saved, jsonCodec{})
+}
+
+// LoadBinary loads a world saved with SaveBinary, see LoadJSON.
+func (w *World) LoadBinary(in io.Reader) error {
+	var saved savedWorld
+	if err := gob.NewDecoder(in).Decode(&saved); err != nil {
+		return fmt.Errorf("ecs: decoding saved world: %w", err)
+	}
+	return w.load(&saved, gobCodec{})
+}
+
+func (w *World) save(c codec) (*savedWorld, error) {
+	saved := &savedWorld{}
+	for _, a := range w.cm.archetypes {
+		for _, ch := range a.chunks {
+			saved.Entities = append(saved.Entities, ch.entities...)
+		}
+	}
+	slices.Sort(saved.Entities)
+	for _, index := range w.em.freeIndices {
+		saved.Free = append(saved.Free, newEntityID(index, w.em.record(index).generation))
+	}
+	for idx, info := range w.cm.components {
+		entities, data, err := info.codec.save(w, idx, c)
+		if err != nil {
+			return nil, fmt.Errorf("ecs: saving component %s: %w", info.name, err)
+		}
+		if entities == nil {
+			continue
+		}
+		saved.Components = append(saved.Components, savedColumn{Name: info.saveName, Entities: entities, Values: data})
+	}
+	for _, id := range w.sing.order {
+		s := w.sing.values[id]
+		info := s.info()
+		if info.saveName == "" {
+			continue
+		}
+		data, err := s.marshal(c)
+		if err != nil {
+			return nil, fmt.Errorf("ecs: saving singleton %s: %w", info.name, err)
+		}
+		saved.Singletons = append(saved.Singletons, savedSingleton{Name: info.saveName, Value: data})
+	}
+	return saved, nil
+}
+
+func (w *World) load(saved *savedWorld, c codec) error {
+	if w.em.len.Load() != 0 || len(w.oplog) != 0 {
+		return ErrWorldNotEmpty
+	}
+
+	components := make(map[string]int, len(w.cm.components))
+	for idx, info := range w.cm.components {
+		components[info.saveName] = idx
+	}
+	columns := make([][]any, len(w.cm.components))
+	live := make(map[EntityID]bool, len(saved.Entities))
+	for _, e := range saved.Entities {
+		if live[e] || e.Generation() == 0 {
+			return fmt.Errorf("ecs: loading entities: invalid entity %v", e)
+		}
+		live[e] = true
+	}
+	for _, col := range saved.Components {
+		idx, ok := components[col.Name]
+		if !ok {
+			return fmt.Errorf("%w: component %q is not registered", ErrUnknownType, col.Name)
+		}
+		info := w.cm.components[idx]
+		values, err := info.codec.load(col.Values, c)
+		if err != nil {
+			return fmt.Errorf("%w: component %s: %w", ErrTypeMismatch, info.name, err)
+		}
+		if len(values) != len(col.Entities) {
+			return fmt.Errorf("ecs: loading component %s: %d values for %d entities", info.name, len(values), len(col.Entities))
+		}
+		seen := make(map[EntityID]bool, len(col.Entities))
+		for _, e := range col.Entities {
+			if !live[e] || seen[e] {
+				return fmt.Errorf("ecs: loading component %s: invalid entity %v", info.name, e)
+			}
+			seen[e] = true
+		}
+		columns[idx] = values
+	}
+
+	singletons := make(map[string]singletonType)
+	for _, id := range w.sing.order {
+		if s := w.sing.values[id]; s.info().saveName != "" {
+			singletons[s.info().saveName] = s
+		}
+	}
+	var commits []func()
+	for _, saved := range saved.Singletons {
+		s, ok := singletons[saved.Name]
+		if !ok {
+			return fmt.Errorf("%w: singleton %q is not registered", ErrUnknownType, saved.Name)
+		}
+		delete(singletons, saved.Name)
+		commit, err := s.unmarshal(saved.Value, c)
+		if err != nil {
+			return fmt.Errorf("%w: singleton %s: %w", ErrTypeMismatch, s.info().name, err)
+		}
+		commits = append(commits, commit)
+	}
+
+	if err := w.em.restore(saved.Entities, saved.Free); err != nil {
+		return err
+	}
+	for _, commit := range commits {
+		commit()
+	}
+	bundles := make(map[EntityID][]componentValue, len(saved.Entities))
+	for _, col := range saved.Components {
+		idx := components[col.Name]
+		for i, e := range col.Entities {
+			bundles[e] = append(bundles[e], componentValue{component: idx, value: columns[idx][i]})
+		}
+	}
+	for _, e := range saved.Entities {
+		rec, _ := w.em.get(e)
+		for _, v := range bundles[e] {
+			rec.pending.set(v.component)
+		}
+		w.oplog = append(w.oplog, oplogEntry{Kind: Create, Entity: e, Value: bundles[e]})
+	}
+	w.cleanup()
+	return nil
+}
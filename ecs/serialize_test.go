@@ -0,0 +1,62 @@
+package ecs
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestLoadWithNewTypes(t *testing.T) {
+	w := New()
+	RegisterComponent[benchPosition](w, Named("position"))
+	parent := NewEntity(w)
+	AddComponent(w, parent, benchPosition{X: 1})
+	child := NewEntity(w)
+	SetParent(w, child, parent)
+	w.Init()
+	var b bytes.Buffer
+	if err := w.SaveJSON(&b); err != nil {
+		t.Fatal(err)
+	}
+
+	// Types registered after the save was made load as empty.
+	loaded := New()
+	RegisterComponent[benchPosition](loaded, Named("position"))
+	RegisterComponent[benchHealth](loaded, Named("health"))
+	health := benchHealth{Value: 10}
+	RegisterSingleton(loaded, &health, Named("health"))
+	if err := loaded.LoadJSON(bytes.NewReader(b.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	if p := GetComponent[benchPosition](loaded, parent); p == nil || p.X != 1 {
+		t.Errorf("position of the parent is %v", p)
+	}
+	if HasComponent[benchHealth](loaded, parent) {
+		t.Error("parent has a component that was not saved")
+	}
+	if health.Value != 10 {
+		t.Errorf("singleton missing from the save was changed to %v", health)
+	}
+	if p, ok := Parent(loaded, child); !ok || p != parent {
+		t.Errorf("parent of the child is %v, want %v", p, parent)
+	}
+}
+
+func TestLoadTypeMismatch(t *testing.T) {
+	w := New()
+	RegisterComponent[benchPosition](w, Named("position"))
+	AddComponent(w, NewEntity(w), benchPosition{X: 1})
+	w.Init()
+	var b bytes.Buffer
+	if err := w.SaveJSON(&b); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := New()
+	RegisterComponent[benchHealth](loaded, Named("position"))
+	err := loaded.LoadJSON(strings.NewReader(strings.Replace(b.String(), `"X": 1`, `"Value": "1"`, 1)))
+	if !errors.Is(err, ErrTypeMismatch) {
+		t.Errorf("loading mismatched values returned %v, want ErrTypeMismatch", err)
+	}
+}
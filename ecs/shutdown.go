@@ -0,0 +1,81 @@
+package ecs
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// ErrClosed is returned when entities are changed after the world has been shut down.
+var ErrClosed = errors.New("ecs: world has been shut down")
+
+// ShutdownSystem is the counterpart of InitSystem. Shutdown systems are run by
+// World.Shutdown.
+type ShutdownSystem interface {
+	Shutdown(*World)
+}
+
+// RegisterShutdownSystem registers new ShutdownSystem to the ecs world.
+func RegisterShutdownSystem(w *World, s ShutdownSystem) {
+	w.shutdownSystems = append(w.shutdownSystems, s)
+}
+
+// Shutdown tears down the world. Pending changes are applied and the shutdown systems
+// are run in reverse registration order, so they can still use the entities and the
+// singletons, for example to save the game. After that all of the entities are removed,
+// which runs the OnRemove hooks of their components, and finally singletons that
+// implement io.Closer are closed in reverse registration order.
+//
+// Errors from closing the singletons are joined to the returned error. Once the world has
+// been shut down, RunUpdate, NewEntity and Spawn panic with ErrClosed and the Try functions
+// that change entities return it. Calling Shutdown again does nothing.
+func (w *World) Shutdown() error {
+	if w.closing {
+		return nil
+	}
+	w.closing = true
+	w.cleanup()
+	for _, s := range slices.Backward(w.shutdownSystems) {
+		s.Shutdown(w)
+	}
+	w.cleanup()
+	// Removal hooks may create new entities, which are removed as well.
+	for w.removeAll() {
+		w.cleanup()
+	}
+	w.mu.Lock()
+	w.closed = true
+	w.mu.Unlock()
+
+	var errs []error
+	for _, id := range slices.Backward(w.sing.order) {
+		c := w.sing.values[id].closer()
+		if c == nil {
+			continue
+		}
+		if err := c.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("closing singleton %T: %w", c, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// removeAll queues all of the entities for removal and reports whether there were any.
+// Descendants that were already queued by the removal of their ancestors are skipped.
+func (w *World) removeAll() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	found := false
+	for _, a := range w.cm.archetypes {
+		for _, c := range a.chunks {
+			for _, e := range c.entities {
+				found = true
+				if rec, ok := w.em.get(e); ok && !rec.removed {
+					w.queueRemoveEntity(e, rec)
+					w.removeDescendants(e)
+				}
+			}
+		}
+	}
+	return found
+}
@@ -0,0 +1,99 @@
+package ecs
+
+import (
+	"errors"
+	"testing"
+)
+
+type recorderDevice struct {
+	closed bool
+}
+
+func (d *recorderDevice) Close() error {
+	d.closed = true
+	return nil
+}
+
+type flushSystem struct {
+	entities *int
+	open     *bool
+}
+
+func (s flushSystem) Shutdown(w *World) {
+	for range Query1[benchPosition](w) {
+		*s.entities++
+	}
+	*s.open = !GetSingleton[recorderDevice](w).closed
+}
+
+func TestShutdownSystemsRunFirst(t *testing.T) {
+	w := New()
+	RegisterComponent[benchPosition](w)
+	device := &recorderDevice{}
+	RegisterSingleton(w, device)
+	var entities int
+	var open bool
+	RegisterShutdownSystem(w, flushSystem{&entities, &open})
+	AddComponent(w, NewEntity(w), benchPosition{})
+	w.Init()
+	// Entities created on the last frame are seen as well.
+	AddComponent(w, NewEntity(w), benchPosition{})
+	if err := w.Shutdown(); err != nil {
+		t.Fatal(err)
+	}
+	if entities != 2 {
+		t.Errorf("shutdown system saw %d entities, want 2", entities)
+	}
+	if !open {
+		t.Error("singleton was closed before the shutdown system ran")
+	}
+	if !device.closed {
+		t.Error("singleton was not closed")
+	}
+}
+
+func TestShutdownHierarchy(t *testing.T) {
+	w := New()
+	RegisterComponent[benchPosition](w)
+	parent := NewEntity(w)
+	for range 4 {
+		child := NewEntity(w)
+		AddComponent(w, child, benchPosition{})
+		SetParent(w, child, parent)
+		parent = child
+	}
+	w.Init()
+	w.RunUpdate(0)
+	if err := w.Shutdown(); err != nil {
+		t.Fatal(err)
+	}
+	for _, a := range w.cm.archetypes {
+		for _, c := range a.chunks {
+			if len(c.entities) > 0 {
+				t.Fatalf("%d entities left after shutdown", len(c.entities))
+			}
+		}
+	}
+}
+
+func TestShutdownClosesWorld(t *testing.T) {
+	w := New()
+	RegisterComponent[benchPosition](w)
+	e := NewEntity(w)
+	w.Init()
+	if err := w.Shutdown(); err != nil {
+		t.Fatal(err)
+	}
+	if err := TryAddComponent(w, e, benchPosition{}); !errors.Is(err, ErrClosed) {
+		t.Errorf("TryAddComponent returned %v, want ErrClosed", err)
+	}
+	if err := TryRemoveEntity(w, e); !errors.Is(err, ErrClosed) {
+		t.Errorf("TryRemoveEntity returned %v, want ErrClosed", err)
+	}
+	defer func() {
+		if r := recover(); r != ErrClosed {
+			t.Errorf("RunUpdate panicked with %v, want ErrClosed", r)
+		}
+	}()
+	w.RunUpdate(0)
+}
@@ -0,0 +1,174 @@
+package ecs
+
+import (
+	"math/bits"
+	"strings"
+)
+
+// Signature is a set of components.
+//
+// Signature grows as needed, so there is no limit on the number of components
+// that can be registered to the world. Signatures are combined with Or:
+//
+//	sig := ecs.Sig[Transform](w).Or(ecs.Sig[Player](w))
+type Signature struct {
+	words []uint64
+}
+
+func sigBit(i int) Signature {
+	var s Signature
+	s.set(i)
+	return s
+}
+
+// Or returns union of s and others.
+func (s Signature) Or(others ...Signature) Signature {
+	n := len(s.words)
+	for _, o := range others {
+		n = max(n, len(o.words))
+	}
+	words := make([]uint64, n)
+	copy(words, s.words)
+	for _, o := range others {
+		for i, w := range o.words {
+			words[i] |= w
+		}
+	}
+	return Signature{words: words}
+}
+
+// AndNot returns the components of s that are not in o.
+func (s Signature) AndNot(o Signature) Signature {
+	words := make([]uint64, len(s.words))
+	for i, w := range s.words {
+		if i < len(o.words) {
+			w &^= o.words[i]
+		}
+		words[i] = w
+	}
+	return Signature{words: trim(words)}
+}
+
+// Contains reports whether s has all of the components of o.
+func (s Signature) Contains(o Signature) bool {
+	if len(o.words) > len(s.words) {
+		return false
+	}
+	for i, w := range o.words {
+		if s.words[i]&w != w {
+			return false
+		}
+	}
+	return true
+}
+
+// Intersects reports whether s and o have any components in common.
+func (s Signature) Intersects(o Signature) bool {
+	n := min(len(s.words), len(o.words))
+	for i := range n {
+		if s.words[i]&o.words[i] != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Equal reports whether s and o have the same components.
+func (s Signature) Equal(o Signature) bool {
+	if len(s.words) != len(o.words) {
+		return false
+	}
+	for i, w := range s.words {
+		if o.words[i] != w {
+			return false
+		}
+	}
+	return true
+}
+
+// IsZero reports whether the signature is empty.
+func (s Signature) IsZero() bool {
+	return len(s.words) == 0
+}
+
+// Len returns the number of components in the signature.
+func (s Signature) Len() int {
+	n := 0
+	for _, w := range s.words {
+		n += bits.OnesCount64(w)
+	}
+	return n
+}
+
+// indices returns the components of the signature in ascending order.
+func (s Signature) indices() []int {
+	var indices []int
+	for i, w := range s.words {
+		for w != 0 {
+			bit := bits.TrailingZeros64(w)
+			indices = append(indices, i*64+bit)
+			w &^= 1 << bit
+		}
+	}
+	return indices
+}
+
+func (s Signature) has(i int) bool {
+	word := i / 64
+	return word < len(s.words) && s.words[word]&(1<<(i%64)) != 0
+}
+
+// set adds component i to the signature in place.
+func (s *Signature) set(i int) {
+	word := i / 64
+	if word >= len(s.words) {
+		words := make([]uint64, word+1)
+		copy(words, s.words)
+		s.words = words
+	}
+	s.words[word] |= 1 << (i % 64)
+}
+
+// unset removes component i from the signature in place.
+func (s *Signature) unset(i int) {
+	word := i / 64
+	if word >= len(s.words) {
+		return
+	}
+	s.words[word] &^= 1 << (i % 64)
+	s.words = trim(s.words)
+}
+
+// with returns copy of s that also has component i.
+func (s Signature) with(i int) Signature {
+	c := Signature{words: append([]uint64(nil), s.words...)}
+	c.set(i)
+	return c
+}
+
+// without returns copy of s that does not have component i.
+func (s Signature) without(i int) Signature {
+	c := Signature{words: append([]uint64(nil), s.words...)}
+	c.unset(i)
+	return c
+}
+
+// key returns the signature in a form usable as a map key.
+func (s Signature) key() string {
+	var b strings.Builder
+	b.Grow(8 * len(s.words))
+	for _, w := range s.words {
+		for i := range 8 {
+			b.WriteByte(byte(w >> (8 * i)))
+		}
+	}
+	return b.String()
+}
+
+// trim drops the trailing empty words, so that equal signatures have equal keys.
+func trim(words []uint64) []uint64 {
+	for len(words) > 0 && words[len(words)-1] == 0 {
+		words = words[:len(words)-1]
+	}
+	return words
+}
@@ -1,36 +1,91 @@
 package ecs
 
-import "fmt"
+import (
+	"fmt"
+	"io"
+)
+
+// SingletonOption configures a singleton registered with RegisterSingleton.
+type SingletonOption interface {
+	applySingleton(*singletonInfo)
+}
+
+type singletonInfo struct {
+	name     string
+	saveName string
+}
 
 type singleton[T any] struct {
 	value *T
+	meta  singletonInfo
+}
+
+func (s singleton[T]) info() singletonInfo {
+	return s.meta
+}
+
+func (s singleton[T]) marshal(c codec) ([]byte, error) {
+	return c.marshal(s.value)
+}
+
+// unmarshal decodes the value and returns a function that stores it to the singleton.
+func (s singleton[T]) unmarshal(data []byte, c codec) (func(), error) {
+	var v T
+	if err := c.unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	return func() { *s.value = v }, nil
+}
+
+func (s singleton[T]) closer() io.Closer {
+	c, _ := any(s.value).(io.Closer)
+	return c
 }
 
 type singletonType interface {
+	closer() io.Closer
+	info() singletonInfo
+	marshal(c codec) ([]byte, error)
+	unmarshal(data []byte, c codec) (func(), error)
 }
 
 type singletonManager struct {
-	values map[uint64]any
+	values map[uint64]singletonType
+	order  []uint64
 }
 
 func newSingletonManager() *singletonManager {
 	return &singletonManager{
-		values: make(map[uint64]any),
+		values: make(map[uint64]singletonType),
 	}
 }
 
-func registerSingleton[T any](sm *singletonManager, s *T) {
+func registerSingleton[T any](sm *singletonManager, s *T, opts []SingletonOption) {
 	var t T
 	name := TypeID(t)
-	sm.values[name] = singleton[T]{value: s}
+	if _, ok := sm.values[name]; !ok {
+		sm.order = append(sm.order, name)
+	}
+	info := singletonInfo{name: fmt.Sprintf("%T", t)}
+	for _, opt := range opts {
+		opt.applySingleton(&info)
+	}
+	sm.values[name] = singleton[T]{value: s, meta: info}
 }
-
 func getSingleton[T any](sm *singletonManager) *T {
+	s, err := lookupSingleton[T](sm)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// lookupSingleton is like getSingleton, but returns ErrNotRegistered instead of panicking.
+func lookupSingleton[T any](sm *singletonManager) (*T, error) {
 	var t T
-	name := TypeID(t)
-	v, ok := sm.values[name].(singleton[T])
+	v, ok := sm.values[TypeID(t)].(singleton[T])
 	if !ok {
-		panic(fmt.Sprintf("singleton of type %T has not been registed", t))
+		return nil, fmt.Errorf("%w: singleton %T", ErrNotRegistered, t)
 	}
-	return v.value
+	return v.value, nil
 }
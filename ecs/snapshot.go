@@ -0,0 +1,259 @@
+package ecs
+
+// Snapshot is an in-memory copy of the state of a world, see World.Snapshot.
+//
+// Snapshots keep their buffers, so taking repeated snapshots with SnapshotInto
+// does not allocate once the buffers have grown large enough.
+type Snapshot struct {
+	world *World
+
+	archetypes []archetypeSnapshot
+	// sparse holds the sparse sets by the component index.
+	sparse  []sparseStorage
+	records []entityRecord
+	// words holds the pending signatures of the records, which end at pendingEnd.
+	words      []uint64
+	pendingEnd []int
+	free       []uint32
+	systems    []systemSnapshot
+	events     map[uint64]eventQueueType
+
+	oplog    []oplogEntry
+	removed  []removedComponent
+	removals int64
+	frame    uint64
+	tick     uint64
+	prevTick uint64
+	fixed    fixedTimestep
+}
+
+type archetypeSnapshot struct {
+	chunks []chunk
+	len    int
+}
+
+type systemSnapshot struct {
+	handle   SystemHandle
+	entities []EntityID
+	lastRun  uint64
+}
+
+// Snapshot copies the state of the world. The world can be returned to the state
+// with Restore.
+//
+// The snapshot has the components, entities, system memberships, queued changes and
+// events of the world, but not the singletons. Snapshots should not be taken during
+// an update cycle.
+func (w *World) Snapshot() *Snapshot {
+	s := &Snapshot{}
+	w.SnapshotInto(s)
+	return s
+}
+
+// SnapshotInto is like Snapshot, but overwrites s and reuses its buffers.
+func (w *World) SnapshotInto(s *Snapshot) {
+	s.world = w
+
+	s.archetypes = resize(s.archetypes, len(w.cm.archetypes))
+	for i, a := range w.cm.archetypes {
+		as := &s.archetypes[i]
+		as.len = a.len
+		as.chunks = resize(as.chunks, len(a.chunks))
+		for j, c := range a.chunks {
+			copyChunk(&as.chunks[j], c)
+		}
+	}
+
+	s.sparse = resize(s.sparse, len(w.cm.sets))
+	for i, set := range w.cm.sets {
+		if set != nil {
+			s.sparse[i] = set.copyInto(s.sparse[i])
+		}
+	}
+
+	n := w.em.len.Load()
+	s.records = s.records[:0]
+	s.words = s.words[:0]
+	s.pendingEnd = s.pendingEnd[:0]
+	for index := range n {
+		rec := *w.em.record(index)
+		s.words = append(s.words, rec.pending.words...)
+		s.pendingEnd = append(s.pendingEnd, len(s.words))
+		rec.pending = Signature{}
+		s.records = append(s.records, rec)
+	}
+	s.free = append(s.free[:0], w.em.freeIndices...)
+
+	s.systems = resize(s.systems, len(w.sm.systems))
+	for i, se := range w.sm.systems {
+		ss := &s.systems[i]
+		ss.handle = se.handle
+		ss.lastRun = se.lastRun
+		ss.entities = append(ss.entities[:0], se.entities...)
+	}
+
+	if s.events == nil {
+		s.events = make(map[uint64]eventQueueType, len(w.events.queues))
+	}
+	for id, q := range w.events.queues {
+		s.events[id] = q.copyInto(s.events[id])
+	}
+	for id := range s.events {
+		if _, ok := w.events.queues[id]; !ok {
+			delete(s.events, id)
+		}
+	}
+
+	s.oplog = append(s.oplog[:0], w.oplog...)
+	s.removed = append(s.removed[:0], w.removed...)
+	s.removals = w.removals.Load()
+	s.frame = w.frame
+	s.tick = w.tick
+	s.prevTick = w.prevTick
+	s.fixed = w.fixed
+}
+
+// Restore returns the world to the state it had when the snapshot was taken.
+// Singletons are not restored.
+//
+// Restoring a snapshot of another world or a snapshot taken before systems were
+// registered or unregistered will panic. Restore should not be called during an
+// update cycle.
+func (w *World) Restore(s *Snapshot) {
+	if s.world != w {
+		panic("snapshot was taken from a different world")
+	}
+	if len(s.systems) != len(w.sm.systems) {
+		panic("systems have changed since the snapshot was taken")
+	}
+	for i, se := range w.sm.systems {
+		if s.systems[i].handle != se.handle {
+			panic("systems have changed since the snapshot was taken")
+		}
+	}
+
+	for i, a := range w.cm.archetypes {
+		var as archetypeSnapshot
+		if i < len(s.archetypes) {
+			as = s.archetypes[i]
+		}
+		restoreArchetype(a, as)
+	}
+
+	for i, set := range w.cm.sets {
+		if set == nil {
+			continue
+		}
+		if i < len(s.sparse) && s.sparse[i] != nil {
+			s.sparse[i].copyInto(set)
+		} else {
+			w.cm.sets[i] = nil
+		}
+	}
+	for i, set := range s.sparse {
+		if set != nil && w.cm.storage(i) == nil {
+			set.copyInto(w.cm.ensureStorage(i))
+		}
+	}
+
+	// Records are given signatures of their own, as pending signatures are modified
+	// in place. The words are copied to a buffer of the world, which is reused as
+	// all of the records that refer to it are replaced.
+	w.restoreWords = append(w.restoreWords[:0], s.words...)
+	words := w.restoreWords
+	start := 0
+	for index, rec := range s.records {
+		end := s.pendingEnd[index]
+		if end > start {
+			rec.pending = Signature{words: words[start:end:end]}
+		}
+		start = end
+		*w.em.record(uint32(index)) = rec
+	}
+	for index := uint32(len(s.records)); index < w.em.len.Load(); index++ {
+		*w.em.record(index) = entityRecord{}
+	}
+	w.em.len.Store(uint32(len(s.records)))
+	w.em.freeIndices = append(w.em.freeIndices[:0], s.free...)
+
+	for i, se := range w.sm.systems {
+		ss := s.systems[i]
+		se.entities = append(se.entities[:0], ss.entities...)
+		clear(se.entitySet)
+		for j, e := range se.entities {
+			se.entitySet[e] = j
+		}
+		se.lastRun = ss.lastRun
+	}
+
+	for id, q := range w.events.queues {
+		if saved, ok := s.events[id]; ok {
+			q.restore(saved)
+		} else {
+			q.restore(nil)
+		}
+	}
+
+	clear(w.oplog)
+	w.oplog = append(w.oplog[:0], s.oplog...)
+	w.reparents = 0
+	for _, op := range w.oplog {
+		if op.Kind == Reparent {
+			w.reparents++
+		}
+	}
+	clear(w.removed)
+	w.removed = append(w.removed[:0], s.removed...)
+	w.removals.Store(s.removals)
+	w.frame = s.frame
+	w.tick = s.tick
+	w.prevTick = s.prevTick
+	w.fixed = s.fixed
+	for _, r := range w.relations {
+		r.invalidate()
+		r.refresh(w)
+	}
+}
+
+// copyChunk copies the entities and the components of src to dst.
+func copyChunk(dst *chunk, src *chunk) {
+	n := len(src.entities)
+	dst.entities = append(dst.entities[:0], src.entities...)
+	if len(dst.columns) != len(src.columns) {
+		dst.columns = make([]columnType, len(src.columns))
+	}
+	for i, col := range src.columns {
+		dst.columns[i] = col.copyInto(dst.columns[i], n)
+	}
+}
+
+// restoreArchetype copies the chunks of the snapshot back to the archetype.
+func restoreArchetype(a *archetype, as archetypeSnapshot) {
+	for i, saved := range as.chunks {
+		if i == len(a.chunks) {
+			a.chunks = append(a.chunks, a.newChunk())
+		}
+		c := a.chunks[i]
+		for row := len(saved.entities); row < len(c.entities); row++ {
+			for _, col := range c.columns {
+				col.zero(row)
+			}
+		}
+		c.entities = append(c.entities[:0], saved.entities...)
+		for j, col := range saved.columns {
+			col.copyInto(c.columns[j], len(saved.entities))
+		}
+	}
+	clear(a.chunks[len(as.chunks):])
+	a.chunks = a.chunks[:len(as.chunks)]
+	a.len = as.len
+}
+
+// resize returns s with length n. Elements within the capacity of s are kept, so
+// that their buffers can be reused.
+func resize[T any](s []T, n int) []T {
+	if n <= cap(s) {
+		return s[:n]
+	}
+	return append(s[:cap(s)], make([]T, n-cap(s))...)
+}
@@ -0,0 +1,17 @@
+package ecs
+
+import "testing"
+
+func TestRestoreDoesNotAllocate(t *testing.T) {
+	w := newBenchWorld()
+	s := w.Snapshot()
+	w.Restore(s)
+	if n := testing.AllocsPerRun(10, func() { w.Restore(s) }); n != 0 {
+		t.Errorf("Restore made %v allocations, want 0", n)
+	}
+	var into Snapshot
+	w.SnapshotInto(&into)
+	if n := testing.AllocsPerRun(10, func() { w.SnapshotInto(&into) }); n != 0 {
+		t.Errorf("SnapshotInto made %v allocations, want 0", n)
+	}
+}
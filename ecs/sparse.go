@@ -0,0 +1,145 @@
+package ecs
+
+import "fmt"
+
+// sparsePageSize is the number of entity indices covered by a single page of a sparse set.
+const sparsePageSize = 1024
+
+// sparseOption is returned by SparseStorage.
+type sparseOption struct{}
+
+func (sparseOption) applyComponent(c *componentInfo) {
+	c.sparse = true
+}
+
+// SparseStorage stores the component in a sparse set instead of the archetype chunks.
+//
+// Entities that have the component still share an archetype, but the values are kept in
+// a single dense array per component. The values are not copied when the entity moves
+// between archetypes, which suits large components and entities whose other components
+// are added and removed often. Iterating over a sparse component is slower than over the
+// archetype columns, as every entity is looked up from the set.
+func SparseStorage() ComponentOption {
+	return sparseOption{}
+}
+
+// sparseStorage is the type erased sparseSet.
+type sparseStorage interface {
+	// cell returns the dense column and the row of the entity's component, or false
+	// if the entity does not have the component.
+	cell(e EntityID) (columnType, int, bool)
+	add(e EntityID, v any, tick uint64)
+	remove(e EntityID)
+	// copyInto copies the set to dst and returns it. New set is allocated if dst is nil.
+	copyInto(dst sparseStorage) sparseStorage
+}
+
+// sparseSet maps entity indices to the rows of a dense column. The indices are split
+// to pages that are allocated when the first entity of the page is added, so lookups
+// are two loads without hashing. Removal swaps the last row to the removed one, which
+// keeps the column dense without a separate compaction.
+type sparseSet[T any] struct {
+	// pages hold the row of each entity index plus one, zero means that the entity
+	// does not have the component.
+	pages    []*[sparsePageSize]uint32
+	entities []EntityID
+	values   column[T]
+}
+
+func newSparseSet[T any]() sparseStorage {
+	return &sparseSet[T]{}
+}
+
+func (s *sparseSet[T]) row(e EntityID) (int, bool) {
+	index := e.Index()
+	p := index / sparsePageSize
+	if int(p) >= len(s.pages) || s.pages[p] == nil {
+		return 0, false
+	}
+	row := int(s.pages[p][index%sparsePageSize]) - 1
+	if row < 0 || s.entities[row] != e {
+		return 0, false
+	}
+	return row, true
+}
+
+func (s *sparseSet[T]) setRow(e EntityID, row int) {
+	index := e.Index()
+	s.pages[index/sparsePageSize][index%sparsePageSize] = uint32(row + 1)
+}
+
+func (s *sparseSet[T]) get(e EntityID) *T {
+	row, ok := s.row(e)
+	if !ok {
+		return nil
+	}
+	return &s.values.data[row]
+}
+
+func (s *sparseSet[T]) cell(e EntityID) (columnType, int, bool) {
+	row, ok := s.row(e)
+	return &s.values, row, ok
+}
+
+func (s *sparseSet[T]) add(e EntityID, v any, tick uint64) {
+	if _, ok := s.row(e); ok {
+		panic(fmt.Sprintf("entity %v already contains component %T", e, v))
+	}
+	p := int(e.Index() / sparsePageSize)
+	for len(s.pages) <= p {
+		s.pages = append(s.pages, nil)
+	}
+	if s.pages[p] == nil {
+		s.pages[p] = new([sparsePageSize]uint32)
+	}
+	var t T
+	s.entities = append(s.entities, e)
+	s.values.data = append(s.values.data, t)
+	s.values.stamp = append(s.values.stamp, componentTicks{})
+	row := len(s.entities) - 1
+	s.values.set(row, v, tick)
+	s.setRow(e, row)
+}
+
+func (s *sparseSet[T]) remove(e EntityID) {
+	row, ok := s.row(e)
+	if !ok {
+		return
+	}
+	last := len(s.entities) - 1
+	if row != last {
+		moved := s.entities[last]
+		s.entities[row] = moved
+		s.values.copyRow(&s.values, row, last)
+		s.setRow(moved, row)
+	}
+	s.values.zero(last)
+	s.entities = s.entities[:last]
+	s.values.data = s.values.data[:last]
+	s.values.stamp = s.values.stamp[:last]
+	index := e.Index()
+	s.pages[index/sparsePageSize][index%sparsePageSize] = 0
+}
+
+func (s *sparseSet[T]) copyInto(dst sparseStorage) sparseStorage {
+	if dst == nil {
+		dst = newSparseSet[T]()
+	}
+	d := dst.(*sparseSet[T])
+	d.pages = resize(d.pages, len(s.pages))
+	for i, p := range s.pages {
+		switch {
+		case p == nil:
+			d.pages[i] = nil
+		case d.pages[i] == nil:
+			d.pages[i] = new([sparsePageSize]uint32)
+			fallthrough
+		default:
+			*d.pages[i] = *p
+		}
+	}
+	d.entities = append(d.entities[:0], s.entities...)
+	d.values.data = append(d.values.data[:0], s.values.data...)
+	d.values.stamp = append(d.values.stamp[:0], s.values.stamp...)
+	return d
+}
@@ -0,0 +1,242 @@
+package ecs
+
+import "testing"
+
+const benchEntities = 10000
+
+type benchPosition struct{ X, Y float32 }
+type benchVelocity struct{ X, Y float32 }
+type benchHealth struct{ Value int }
+
+type benchSystem struct{}
+
+func (benchSystem) Update(UpdateState) {}
+
+func newBenchWorld(opts ...ComponentOption) *World {
+	w := New()
+	RegisterComponent[benchPosition](w, opts...)
+	RegisterComponent[benchVelocity](w, opts...)
+	RegisterComponent[benchHealth](w)
+	for i := range benchEntities {
+		e := NewEntity(w)
+		AddComponent(w, e, benchPosition{})
+		AddComponent(w, e, benchVelocity{X: 1, Y: 1})
+		if i%2 == 0 {
+			AddComponent(w, e, benchHealth{Value: 10})
+		}
+	}
+	RegisterSystem(w, benchSystem{}, Sig[benchPosition](w).Or(Sig[benchVelocity](w)))
+	w.Init()
+	return w
+}
+
+// BenchmarkGetComponent looks up two components of every entity with GetComponent.
+// It only uses the API that predates the archetype storage, so the same benchmark
+// can be run on older revisions to compare the storages.
+func BenchmarkGetComponent(b *testing.B) {
+	w := New()
+	RegisterComponent[benchPosition](w)
+	RegisterComponent[benchVelocity](w)
+	entities := make([]EntityID, 0, benchEntities)
+	for range benchEntities {
+		e := NewEntity(w)
+		AddComponent(w, e, benchPosition{})
+		AddComponent(w, e, benchVelocity{X: 1, Y: 1})
+		entities = append(entities, e)
+	}
+	w.Init()
+	b.ResetTimer()
+	for b.Loop() {
+		for _, e := range entities {
+			p := GetComponent[benchPosition](w, e)
+			v := GetComponent[benchVelocity](w, e)
+			p.X += v.X
+			p.Y += v.Y
+		}
+	}
+}
+
+// BenchmarkRawComponentArrayGet measures the lookups of the previous map indexed
+// storage without the world around it. It is the raw counterpart of BenchmarkSparseSetGet.
+func BenchmarkRawComponentArrayGet(b *testing.B) {
+	positions := newComponentArray[benchPosition]()
+	velocities := newComponentArray[benchVelocity]()
+	entities := make([]EntityID, 0, benchEntities)
+	for i := range benchEntities {
+		e := EntityID(i)
+		positions.add(0, e, benchPosition{})
+		velocities.add(0, e, benchVelocity{X: 1, Y: 1})
+		entities = append(entities, e)
+	}
+	b.ResetTimer()
+	for b.Loop() {
+		for _, e := range entities {
+			p := positions.Get(1, e)
+			v := velocities.Get(1, e)
+			p.X += v.X
+			p.Y += v.Y
+		}
+	}
+}
+
+func BenchmarkSparseSetGet(b *testing.B) {
+	positions := newSparseSet[benchPosition]().(*sparseSet[benchPosition])
+	velocities := newSparseSet[benchVelocity]().(*sparseSet[benchVelocity])
+	entities := make([]EntityID, 0, benchEntities)
+	for i := range benchEntities {
+		e := newEntityID(uint32(i), 1)
+		positions.add(e, benchPosition{}, 0)
+		velocities.add(e, benchVelocity{X: 1, Y: 1}, 0)
+		entities = append(entities, e)
+	}
+	b.ResetTimer()
+	for b.Loop() {
+		for _, e := range entities {
+			p := positions.get(e)
+			v := velocities.get(e)
+			p.X += v.X
+			p.Y += v.Y
+		}
+	}
+}
+
+// BenchmarkComponentArrayChurn removes the component from every other entity and
+// adds it back, which makes the map indexed storage prune and rebuild its index.
+func BenchmarkComponentArrayChurn(b *testing.B) {
+	positions := newComponentArray[benchPosition]()
+	for i := range benchEntities {
+		positions.add(0, EntityID(i), benchPosition{})
+	}
+	b.ResetTimer()
+	for b.Loop() {
+		for i := 0; i < benchEntities; i += 2 {
+			positions.remove(0, EntityID(i))
+		}
+		positions.prune()
+		for i := 0; i < benchEntities; i += 2 {
+			positions.add(0, EntityID(i), benchPosition{})
+		}
+	}
+}
+
+func BenchmarkSparseSetChurn(b *testing.B) {
+	positions := newSparseSet[benchPosition]().(*sparseSet[benchPosition])
+	for i := range benchEntities {
+		positions.add(newEntityID(uint32(i), 1), benchPosition{}, 0)
+	}
+	b.ResetTimer()
+	for b.Loop() {
+		for i := 0; i < benchEntities; i += 2 {
+			positions.remove(newEntityID(uint32(i), 1))
+		}
+		for i := 0; i < benchEntities; i += 2 {
+			positions.add(newEntityID(uint32(i), 1), benchPosition{}, 0)
+		}
+	}
+}
+
+// BenchmarkSparseGetComponent is like BenchmarkGetComponent with the components in sparse sets.
+func BenchmarkSparseGetComponent(b *testing.B) {
+	w := newBenchWorld(SparseStorage())
+	entities := w.sm.systems[0].entities
+	b.ResetTimer()
+	for b.Loop() {
+		for _, e := range entities {
+			p := GetComponent[benchPosition](w, e)
+			v := GetComponent[benchVelocity](w, e)
+			p.X += v.X
+			p.Y += v.Y
+		}
+	}
+}
+
+func BenchmarkArchetypeChunks(b *testing.B) {
+	w := newBenchWorld()
+	s := w.sm.systems[0]
+	pIdx := getComponentIdx[benchPosition](w.cm)
+	vIdx := getComponentIdx[benchVelocity](w.cm)
+	b.ResetTimer()
+	for b.Loop() {
+		for _, a := range s.archetypes {
+			pCol, vCol := a.column(pIdx), a.column(vIdx)
+			for _, c := range a.chunks {
+				positions := c.columns[pCol].(*column[benchPosition]).data
+				velocities := c.columns[vCol].(*column[benchVelocity]).data
+				for i := range c.entities {
+					positions[i].X += velocities[i].X
+					positions[i].Y += velocities[i].Y
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkQuery2(b *testing.B) {
+	w := newBenchWorld()
+	b.ResetTimer()
+	for b.Loop() {
+		for _, r := range Query2[benchPosition, benchVelocity](w) {
+			r.A.X += r.B.X
+			r.A.Y += r.B.Y
+		}
+	}
+}
+
+func BenchmarkQuery2Sparse(b *testing.B) {
+	w := newBenchWorld(SparseStorage())
+	b.ResetTimer()
+	for b.Loop() {
+		for _, r := range Query2[benchPosition, benchVelocity](w) {
+			r.A.X += r.B.X
+			r.A.Y += r.B.Y
+		}
+	}
+}
+
+func BenchmarkSnapshotInto(b *testing.B) {
+	w := newBenchWorld()
+	var s Snapshot
+	b.ResetTimer()
+	for b.Loop() {
+		w.SnapshotInto(&s)
+	}
+}
+
+func BenchmarkRestore(b *testing.B) {
+	w := newBenchWorld()
+	s := w.Snapshot()
+	b.ResetTimer()
+	for b.Loop() {
+		w.Restore(s)
+	}
+}
+
+type benchSelected struct{}
+
+// benchToggle adds and removes benchSelected on every entity. Both the tag and the
+// component move the entity between archetypes, which dominates the cost, so the
+// tag only saves the copying of the column and the ticks.
+func benchToggle(b *testing.B, register func(*World)) {
+	w := newBenchWorld()
+	register(w)
+	entities := w.sm.systems[0].entities
+	b.ResetTimer()
+	for b.Loop() {
+		for _, e := range entities {
+			AddComponent(w, e, benchSelected{})
+		}
+		w.cleanup()
+		for _, e := range entities {
+			RemoveComponent[benchSelected](w, e)
+		}
+		w.cleanup()
+	}
+}
+
+func BenchmarkToggleComponent(b *testing.B) {
+	benchToggle(b, func(w *World) { RegisterComponent[benchSelected](w) })
+}
+
+func BenchmarkToggleTag(b *testing.B) {
+	benchToggle(b, func(w *World) { RegisterTag[benchSelected](w) })
+}
@@ -2,6 +2,7 @@ package ecs
 
 import (
 	"fmt"
+	"slices"
 )
 
 type SystemType interface {
@@ -12,75 +13,190 @@ type UpdateState struct {
 	World     *World
 	Entities  []EntityID
 	DeltaTime float32
+	// Alpha is the fraction of the fixed timestep that has accumulated since the last
+	// FixedUpdate step, see World.SetFixedTimestep. It is 0 for the FixedUpdate systems
+	// and 1 when fixed timestep is not used.
+	Alpha float32
+
+	system *systemEntry
 }
 
+// SystemHandle identifies a registered system instance.
+type SystemHandle uint64
+
 type systemManager struct {
-	systems          []SystemType
-	systemEntities   [][]EntityID
-	systemEntitySet  []map[uint64]int
-	systemSignatures []Signature
-	systemIdx        map[uint64]int
-	// idx              int
+	systems    []*systemEntry
+	byType     map[uint64][]*systemEntry
+	handles    map[SystemHandle]*systemEntry
+	nextHandle SystemHandle
+	schedule   []*systemEntry
+	batches    [][]*systemEntry
+	active     []*systemEntry
+	parallel   bool
+	dirty      bool
+}
+
+// systemEntry keeps track of the entities and archetypes matching the system's Signature.
+type systemEntry struct {
+	system     SystemType
+	handle     SystemHandle
+	typeID     uint64
+	sig        Signature
+	terms      terms
+	entities   []EntityID
+	entitySet  map[EntityID]int
+	archetypes []*archetype
+	lastRun    uint64
+
+	stage  Stage
+	before []uint64
+	after  []uint64
+
+	access access
+	// serialized tells why the system could not run in parallel with the previous systems.
+	serialized string
+
+	disabled     bool
+	conditions   []Condition
+	unregistered bool
 }
 
 func newSystemManager() *systemManager {
 	return &systemManager{
-		systemIdx: make(map[uint64]int),
+		byType:  make(map[uint64][]*systemEntry),
+		handles: make(map[SystemHandle]*systemEntry),
 	}
 }
 
-func getSystemIdx[T SystemType](sm *systemManager) int {
+// getSystem returns the only registered system of type T.
+func getSystem[T SystemType](sm *systemManager) *systemEntry {
 	var s T
-	typeName := TypeID(s)
-	idx, ok := sm.systemIdx[typeName]
+	systems := sm.byType[TypeID(s)]
+	switch len(systems) {
+	case 0:
+		panic(fmt.Sprintf("system %T is not registered with SystemManager", s))
+	case 1:
+		return systems[0]
+	}
+	panic(fmt.Sprintf("system %T has %d instances, use its SystemHandle instead", s, len(systems)))
+}
+
+func (sm *systemManager) getHandle(h SystemHandle) *systemEntry {
+	se, ok := sm.handles[h]
 	if !ok {
-		panic(fmt.Sprintf("component %v is not registered with ComponentManager", typeName))
+		panic(fmt.Sprintf("system handle %d is not registered with SystemManager", h))
 	}
-	return idx
+	return se
 }
 
-func registerSystem[T SystemType](sm *systemManager, s T, sig Signature) {
-	name := TypeID(s)
-	if _, ok := sm.systemIdx[name]; ok {
-		panic(fmt.Sprintf("system %T is already registered to SystemManager", s))
+func registerSystem[T SystemType](sm *systemManager, s T, sig Signature, opts []SystemOption) *systemEntry {
+	sm.nextHandle++
+	entry := &systemEntry{
+		system:    s,
+		handle:    sm.nextHandle,
+		typeID:    TypeID(s),
+		stage:     Update,
+		entities:  make([]EntityID, 0, initialEntityArraySize),
+		entitySet: make(map[EntityID]int),
+	}
+	for _, opt := range opts {
+		opt.applySystem(entry)
 	}
-	idx := len(sm.systems)
-	sm.systems = append(sm.systems, s)
-	sm.systemEntities = append(sm.systemEntities, make([]EntityID, 0, initialEntityArraySize))
-	sm.systemSignatures = append(sm.systemSignatures, sig)
-	sm.systemEntitySet = append(sm.systemEntitySet, make(map[uint64]int))
-	sm.systemIdx[name] = idx
-	// sm.idx++
+	entry.sig = sig.AndNot(entry.terms.optional)
+	sm.systems = append(sm.systems, entry)
+	sm.byType[entry.typeID] = append(sm.byType[entry.typeID], entry)
+	sm.handles[entry.handle] = entry
+	sm.dirty = true
+	return entry
 }
 
-func (sm *systemManager) addEntity(e EntityID, sig Signature) {
-	for i := range sm.systems {
-		// Check if the system is setup to listen for these entities
-		if sm.systemSignatures[i]&sig != sm.systemSignatures[i] {
-			continue
+// unregister removes the system and drops its entities.
+func (sm *systemManager) unregister(se *systemEntry) {
+	isEntry := func(other *systemEntry) bool { return other == se }
+	sm.systems = slices.DeleteFunc(sm.systems, isEntry)
+	sm.byType[se.typeID] = slices.DeleteFunc(sm.byType[se.typeID], isEntry)
+	if len(sm.byType[se.typeID]) == 0 {
+		delete(sm.byType, se.typeID)
+	}
+	delete(sm.handles, se.handle)
+	se.unregistered = true
+	se.entities = nil
+	se.entitySet = nil
+	se.archetypes = nil
+	sm.dirty = true
+}
+
+func (se *systemEntry) matches(sig Signature) bool {
+	return sig.Contains(se.sig) && !sig.Intersects(se.terms.without)
+}
+
+// addArchetype starts tracking the archetype and all of its current entities
+// if it matches the system.
+func (se *systemEntry) addArchetype(a *archetype) {
+	if !se.matches(a.sig) {
+		return
+	}
+	se.archetypes = append(se.archetypes, a)
+	for _, c := range a.chunks {
+		for _, e := range c.entities {
+			se.add(e)
 		}
-		if _, ok := sm.systemEntitySet[i][uint64(e)]; ok {
-			continue
+	}
+}
+
+func (se *systemEntry) add(e EntityID) {
+	if _, ok := se.entitySet[e]; ok {
+		return
+	}
+	se.entitySet[e] = len(se.entities)
+	se.entities = append(se.entities, e)
+}
+
+func (se *systemEntry) remove(e EntityID) {
+	idx, ok := se.entitySet[e]
+	if !ok {
+		return
+	}
+	end := len(se.entities) - 1
+	lastEntity := se.entities[end]
+	se.entities[idx] = lastEntity
+	se.entitySet[lastEntity] = idx
+	se.entities = se.entities[:end]
+	delete(se.entitySet, e)
+}
+
+func (sm *systemManager) addArchetype(a *archetype) {
+	for _, s := range sm.systems {
+		if s.matches(a.sig) {
+			s.archetypes = append(s.archetypes, a)
 		}
-		idx := len(sm.systemEntities[i])
-		sm.systemEntities[i] = append(sm.systemEntities[i], e)
-		sm.systemEntitySet[i][uint64(e)] = idx
 	}
 }
 
-func (sm *systemManager) removeEntity(e EntityID, sig Signature) {
-	for i := range sm.systems {
-		// Check if the system is setup to listen for these entities
-		if sm.systemSignatures[i]&sig != sm.systemSignatures[i] {
-			continue
+// updateEntity moves the entity in or out of the systems whose match changed
+// when the entity's signature changed from oldSig to newSig.
+func (sm *systemManager) updateEntity(e EntityID, oldSig, newSig Signature) {
+	for _, s := range sm.systems {
+		was, is := s.matches(oldSig), s.matches(newSig)
+		switch {
+		case is && !was:
+			s.add(e)
+		case was && !is:
+			s.remove(e)
 		}
-		idx, ok := sm.systemEntitySet[i][uint64(e)]
-		if !ok {
-			continue
+	}
+}
+
+func (sm *systemManager) addEntity(e EntityID, sig Signature) {
+	for _, s := range sm.systems {
+		if s.matches(sig) {
+			s.add(e)
 		}
-		end := len(sm.systemEntities[i]) - 1
-		lastEntity := sm.systemEntities[i][end]
-		sm.systemEntitySet[i][uint64(lastEntity)] = idx
-		sm.systemEntities[i] = sm.systemEntities[i][:end]
+	}
+}
+
+func (sm *systemManager) removeEntity(e EntityID) {
+	for _, s := range sm.systems {
+		s.remove(e)
 	}
 }
@@ -0,0 +1,59 @@
+package ecs
+
+import (
+	"fmt"
+	"math"
+)
+
+// fixedTimestep accumulates the frame time and tells how many fixed steps
+// should be run on each update cycle.
+type fixedTimestep struct {
+	step        float64
+	maxSteps    int
+	accumulator float64
+}
+
+// advance adds dt to the accumulator and returns the number of fixed steps to run
+// and the interpolation alpha between the last two steps. If the steps would exceed
+// maxSteps, the whole steps that were left over are dropped so that slow frames do
+// not cause ever growing amount of work.
+func (f *fixedTimestep) advance(dt float32) (int, float32) {
+	if f.step == 0 {
+		return 1, 1
+	}
+	f.accumulator += float64(dt)
+	steps := int(f.accumulator / f.step)
+	if steps > f.maxSteps {
+		steps = f.maxSteps
+		f.accumulator = math.Mod(f.accumulator, f.step)
+	} else {
+		f.accumulator -= float64(steps) * f.step
+	}
+	return steps, float32(f.accumulator / f.step)
+}
+
+// SetFixedTimestep makes the systems in FixedUpdate stage run tickRate times per second.
+//
+// On each RunUpdate the frame time is added to an accumulator and the FixedUpdate systems are
+// run zero or more times with DeltaTime of 1/tickRate, but at most maxSteps times. The time
+// that is left in the accumulator is given to the systems of the later stages as UpdateState.Alpha,
+// which can be used to interpolate between the last two fixed steps.
+//
+// Each event is read by only one fixed step, see Read. Structural changes made during the fixed
+// steps are applied at the end of the update cycle as usual.
+//
+// Calling this with tickRate of 0 disables the fixed timestep, so that FixedUpdate systems run
+// once per update cycle. Calling this with negative tickRate or maxSteps less than 1 will panic.
+func (w *World) SetFixedTimestep(tickRate float32, maxSteps int) {
+	if tickRate == 0 {
+		w.fixed = fixedTimestep{}
+		return
+	}
+	if tickRate < 0 || maxSteps < 1 {
+		panic(fmt.Sprintf("invalid fixed timestep: tick rate %v, max steps %d", tickRate, maxSteps))
+	}
+	w.fixed = fixedTimestep{
+		step:     1 / float64(tickRate),
+		maxSteps: maxSteps,
+	}
+}
@@ -8,26 +8,39 @@ import (
 
 var (
 	typeIDCounter uint64
-	typeIDCache   = make(map[reflect.Type]uint64)
-	cacheMu       sync.RWMutex
+	typeIDCache   atomic.Pointer[map[reflect.Type]uint64]
+	cacheMu       sync.Mutex
 )
 
+// TypeID returns process wide unique identifier for the type of v.
+//
+// Lookups of already seen types do not take any locks, which keeps the
+// component accessors cheap.
 func TypeID[T any](v T) uint64 {
 	typ := reflect.TypeOf(v)
-	cacheMu.RLock()
-	if id, ok := typeIDCache[typ]; ok {
-		cacheMu.RUnlock()
-		return id
+	if cache := typeIDCache.Load(); cache != nil {
+		if id, ok := (*cache)[typ]; ok {
+			return id
+		}
 	}
-	cacheMu.RUnlock()
 
 	cacheMu.Lock()
-	if id, ok := typeIDCache[typ]; ok {
-		cacheMu.Unlock()
-		return id
+	defer cacheMu.Unlock()
+	cache := typeIDCache.Load()
+	if cache != nil {
+		if id, ok := (*cache)[typ]; ok {
+			return id
+		}
+	}
+	next := make(map[reflect.Type]uint64, 1)
+	if cache != nil {
+		next = make(map[reflect.Type]uint64, len(*cache)+1)
+		for k, v := range *cache {
+			next[k] = v
+		}
 	}
 	id := atomic.AddUint64(&typeIDCounter, 1)
-	typeIDCache[typ] = id
-	cacheMu.Unlock()
+	next[typ] = id
+	typeIDCache.Store(&next)
 	return id
 }
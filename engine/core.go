@@ -2,14 +2,24 @@ package engine
 
 import (
 	"image/color"
+	"io"
 	"log"
 
 	"github.com/MatiasLyyra/mengine/ecs"
 	rl "github.com/gen2brain/raylib-go/raylib"
 )
 
+// Default fixed timestep of the world, see ecs.World.SetFixedTimestep.
+const (
+	DefaultTickRate = 60
+	DefaultMaxSteps = 5
+)
+
 type Engine struct {
 	World *ecs.World
+	// Input is the source of the frames. It reads the window by default, and can be
+	// replaced with a Recorder or a Replayer before calling Run.
+	Input InputSource
 }
 
 type WindowSystem struct{}
@@ -27,6 +37,11 @@ func (ws *WindowSystem) Init(w *ecs.World) {
 	log.Printf("Initialized window")
 }
 
+func (ws *WindowSystem) Shutdown(w *ecs.World) {
+	rl.CloseWindow()
+	log.Printf("Closed window")
+}
+
 type WindowSettings struct {
 	Width       int32
 	Height      int32
@@ -47,26 +62,40 @@ var window Window
 func New() *Engine {
 	e := &Engine{
 		World: ecs.New(),
+		Input: &windowSource{},
 	}
 	ecs.RegisterSingleton(e.World, &window)
+	ecs.RegisterSingleton(e.World, &Input{})
 	ecs.RegisterSingleton(e.World, &WindowSettings{
 		LogLevel: rl.LogTrace,
 	})
-	ecs.RegisterInitSystem(e.World, &WindowSystem{})
+	windowSystem := &WindowSystem{}
+	ecs.RegisterInitSystem(e.World, windowSystem)
+	ecs.RegisterShutdownSystem(e.World, windowSystem)
+	e.World.SetFixedTimestep(DefaultTickRate, DefaultMaxSteps)
 	return e
 }
 
 func (e *Engine) Run() {
 	e.World.Init()
-	defer rl.CloseWindow()
+	defer func() {
+		if err := e.World.Shutdown(); err != nil {
+			log.Printf("Shutdown: %v", err)
+		}
+	}()
 	for !rl.WindowShouldClose() {
-		window.Width = float32(rl.GetScreenWidth())
-		window.Height = float32(rl.GetScreenHeight())
-		dt := rl.GetFrameTime()
+		frame, err := e.Input.NextFrame()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			log.Printf("Input: %v", err)
+			return
+		}
 		rl.ClearBackground(rl.Black)
 		rl.DrawFPS(10, 10)
 		rl.BeginDrawing()
-		e.World.RunUpdate(dt)
+		Step(e.World, frame)
 		rl.EndDrawing()
 	}
 }
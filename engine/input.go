@@ -0,0 +1,210 @@
+package engine
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/bits"
+
+	"github.com/MatiasLyyra/mengine/ecs"
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// keyCount is the number of raylib key codes tracked by Input.
+const keyCount = 384
+
+// KeySet is a set of keys held down.
+type KeySet [keyCount / 64]uint64
+
+func (k *KeySet) set(key int32) {
+	k[key/64] |= 1 << (key % 64)
+}
+
+func (k *KeySet) unset(key int32) {
+	k[key/64] &^= 1 << (key % 64)
+}
+
+// Has reports whether the key is in the set.
+func (k KeySet) Has(key int32) bool {
+	if key < 0 || key >= keyCount {
+		return false
+	}
+	return k[key/64]&(1<<(key%64)) != 0
+}
+
+// Frame is everything that the world reads from outside on a single frame.
+// Running the same frames through a world reproduces the same updates.
+type Frame struct {
+	DeltaTime float32
+	Width     float32
+	Height    float32
+	Keys      KeySet
+}
+
+// InputSource provides the frames that the engine runs.
+//
+// NextFrame returns io.EOF when there are no more frames.
+type InputSource interface {
+	NextFrame() (Frame, error)
+}
+
+// windowSource reads the frames from the raylib window.
+//
+// Instead of polling every key, the keys pressed on the frame are taken from the
+// raylib key queue, and only the keys that are held are polled for their release.
+type windowSource struct {
+	held KeySet
+}
+
+func (s *windowSource) NextFrame() (Frame, error) {
+	for key := rl.GetKeyPressed(); key != 0; key = rl.GetKeyPressed() {
+		if key > 0 && key < keyCount {
+			s.held.set(key)
+		}
+	}
+	for i, word := range s.held {
+		for ; word != 0; word &= word - 1 {
+			key := int32(i*64 + bits.TrailingZeros64(word))
+			if !rl.IsKeyDown(key) {
+				s.held.unset(key)
+			}
+		}
+	}
+	return Frame{
+		DeltaTime: rl.GetFrameTime(),
+		Width:     float32(rl.GetScreenWidth()),
+		Height:    float32(rl.GetScreenHeight()),
+		Keys:      s.held,
+	}, nil
+}
+
+// recordingMagic starts the recording files, followed by recordingVersion.
+const (
+	recordingMagic   = "MREC"
+	recordingVersion = uint32(1)
+)
+
+// Recorder is an InputSource that writes the frames of another source as they are read.
+type Recorder struct {
+	src    InputSource
+	out    *bufio.Writer
+	header bool
+}
+
+// NewRecorder returns a Recorder that reads the frames from src and writes them to out.
+// The frames are buffered, so Close must be called before out is closed.
+func NewRecorder(src InputSource, out io.Writer) *Recorder {
+	return &Recorder{src: src, out: bufio.NewWriter(out)}
+}
+
+// Close writes the buffered frames to the output. It does not close the output.
+func (r *Recorder) Close() error {
+	if err := r.out.Flush(); err != nil {
+		return fmt.Errorf("writing recording: %w", err)
+	}
+	return nil
+}
+
+func (r *Recorder) NextFrame() (Frame, error) {
+	f, err := r.src.NextFrame()
+	if err != nil {
+		return f, err
+	}
+	if !r.header {
+		if _, err := io.WriteString(r.out, recordingMagic); err != nil {
+			return f, fmt.Errorf("writing recording: %w", err)
+		}
+		if err := binary.Write(r.out, binary.LittleEndian, recordingVersion); err != nil {
+			return f, fmt.Errorf("writing recording: %w", err)
+		}
+		r.header = true
+	}
+	if err := binary.Write(r.out, binary.LittleEndian, &f); err != nil {
+		return f, fmt.Errorf("writing recording: %w", err)
+	}
+	return f, nil
+}
+
+// Replayer is an InputSource that reads the frames written by a Recorder.
+type Replayer struct {
+	in io.Reader
+}
+
+// NewReplayer reads the header of the recording and returns a Replayer for its frames.
+func NewReplayer(in io.Reader) (*Replayer, error) {
+	var magic [len(recordingMagic)]byte
+	var version uint32
+	if _, err := io.ReadFull(in, magic[:]); err != nil || string(magic[:]) != recordingMagic {
+		return nil, errors.New("reading recording: not a recording file")
+	}
+	if err := binary.Read(in, binary.LittleEndian, &version); err != nil {
+		return nil, fmt.Errorf("reading recording: %w", err)
+	}
+	if version != recordingVersion {
+		return nil, fmt.Errorf("reading recording: unsupported version %d", version)
+	}
+	return &Replayer{in: in}, nil
+}
+
+func (r *Replayer) NextFrame() (Frame, error) {
+	var f Frame
+	err := binary.Read(r.in, binary.LittleEndian, &f)
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		return f, errors.New("reading recording: truncated frame")
+	}
+	return f, err
+}
+
+// Input is a singleton with the keyboard state of the current frame. Systems should
+// read the keyboard through it instead of raylib, so that the input can be recorded.
+type Input struct {
+	keys KeySet
+	prev KeySet
+}
+
+// IsKeyDown reports whether the key is held down.
+func (in *Input) IsKeyDown(key int32) bool {
+	return in.keys.Has(key)
+}
+
+// IsKeyPressed reports whether the key went down on this frame.
+func (in *Input) IsKeyPressed(key int32) bool {
+	return in.keys.Has(key) && !in.prev.Has(key)
+}
+
+// IsKeyReleased reports whether the key went up on this frame.
+func (in *Input) IsKeyReleased(key int32) bool {
+	return !in.keys.Has(key) && in.prev.Has(key)
+}
+
+// Step runs a single update of the world with the frame.
+func Step(w *ecs.World, f Frame) {
+	in := ecs.GetSingleton[Input](w)
+	in.prev = in.keys
+	in.keys = f.Keys
+	win := ecs.GetSingleton[Window](w)
+	win.Width = f.Width
+	win.Height = f.Height
+	w.RunUpdate(f.DeltaTime)
+}
+
+// Replay runs all of the frames of the recording in the world without a window.
+// The world must be initialized and have the Input and Window singletons.
+func Replay(w *ecs.World, in io.Reader) error {
+	r, err := NewReplayer(in)
+	if err != nil {
+		return err
+	}
+	for {
+		f, err := r.NextFrame()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		Step(w, f)
+	}
+}
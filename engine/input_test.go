@@ -0,0 +1,105 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+)
+
+// frameSource returns the frames in order and then io.EOF.
+type frameSource []Frame
+
+func (s *frameSource) NextFrame() (Frame, error) {
+	if len(*s) == 0 {
+		return Frame{}, io.EOF
+	}
+	f := (*s)[0]
+	*s = (*s)[1:]
+	return f, nil
+}
+
+func testFrames() []Frame {
+	var frames []Frame
+	for i := range 10 {
+		f := Frame{DeltaTime: float32(i) / 60, Width: 800, Height: 600}
+		f.Keys.set(int32(32 + i))
+		f.Keys.set(keyCount - 1)
+		frames = append(frames, f)
+	}
+	return frames
+}
+
+// record writes the frames with a Recorder.
+func record(t *testing.T, frames []Frame) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	src := frameSource(frames)
+	r := NewRecorder(&src, &b)
+	for {
+		_, err := r.NextFrame()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+func TestRecordReplay(t *testing.T) {
+	frames := testFrames()
+	r, err := NewReplayer(bytes.NewReader(record(t, frames)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range frames {
+		got, err := r.NextFrame()
+		if err != nil {
+			t.Fatalf("frame %d: %v", i, err)
+		}
+		if got != want {
+			t.Fatalf("frame %d is %+v, want %+v", i, got, want)
+		}
+	}
+	if _, err := r.NextFrame(); err != io.EOF {
+		t.Fatalf("reading past the last frame returned %v, want io.EOF", err)
+	}
+}
+
+func TestReplayTruncated(t *testing.T) {
+	data := record(t, testFrames())
+	r, err := NewReplayer(bytes.NewReader(data[:len(data)-3]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for {
+		_, err := r.NextFrame()
+		if err == nil {
+			continue
+		}
+		if err == io.EOF {
+			t.Fatal("truncated recording was read to the end")
+		}
+		break
+	}
+}
+
+func TestReplayHeader(t *testing.T) {
+	data := record(t, testFrames())
+	wrongVersion := bytes.Clone(data)
+	binary.LittleEndian.PutUint32(wrongVersion[len(recordingMagic):], recordingVersion+1)
+	if _, err := NewReplayer(bytes.NewReader(wrongVersion)); err == nil {
+		t.Error("recording of a different version was accepted")
+	}
+	if _, err := NewReplayer(bytes.NewReader([]byte("NOTAREC"))); err == nil {
+		t.Error("file without the recording header was accepted")
+	}
+	if _, err := NewReplayer(bytes.NewReader(data[:len(recordingMagic)+2])); err == nil {
+		t.Error("recording with truncated header was accepted")
+	}
+}
@@ -1,8 +1,12 @@
 package main
 
 import (
+	"bufio"
+	"flag"
 	"image/color"
+	"log"
 	"math/rand"
+	"os"
 
 	"github.com/MatiasLyyra/mengine/ecs"
 	"github.com/MatiasLyyra/mengine/engine"
@@ -12,6 +16,10 @@ import (
 type Transform struct {
 	Position rl.Vector2
 	Rotation float32
+
+	// PrevPosition is the position before the last fixed step, used for interpolating
+	// the position when drawing.
+	PrevPosition rl.Vector2
 }
 
 type PlayerGraphics struct {
@@ -40,17 +48,16 @@ type Player struct {
 type DrawPlayerSystem struct{}
 
 func (mbs DrawPlayerSystem) Update(us ecs.UpdateState) {
-	for _, e := range us.Entities {
-		ball := ecs.GetComponent[PlayerGraphics](us.World, e)
-		transform := ecs.GetComponent[Transform](us.World, e)
-		player := ecs.GetComponent[Player](us.World, e)
+	for _, r := range ecs.Query3[PlayerGraphics, Transform, Player](us) {
+		ball, transform, player := r.A, r.B, r.C
 		playerColor := ball.Color
 		if player.DashRemaining > 0 {
 			playerColor = ball.OnDashColor
 		} else if player.DashCooldown > 0 {
 			playerColor = ball.OnCooldownColor
 		}
-		rl.DrawCircle(int32(transform.Position.X), int32(transform.Position.Y), ball.Radius, playerColor)
+		pos := rl.Vector2Lerp(transform.PrevPosition, transform.Position, us.Alpha)
+		rl.DrawCircle(int32(pos.X), int32(pos.Y), ball.Radius, playerColor)
 	}
 }
 
@@ -59,10 +66,9 @@ type PlayerDashSystem struct{}
 func (mbs PlayerDashSystem) Update(us ecs.UpdateState) {
 
 	values := ecs.GetSingleton[PlayerValues](us.World)
-	for _, e := range us.Entities {
-		player := ecs.GetComponent[Player](us.World, e)
-
-		if player.DashCooldown <= 0 && rl.IsKeyPressed(rl.KeySpace) {
+	input := ecs.GetSingleton[engine.Input](us.World)
+	for _, player := range ecs.Query1[Player](us) {
+		if player.DashCooldown <= 0 && input.IsKeyPressed(rl.KeySpace) {
 			player.DashCooldown = values.DashCooldown
 			player.Speed = values.BaseSpeed + values.DashSpeed
 			player.DashRemaining = values.DashDuration
@@ -83,15 +89,15 @@ type MovePlayerSystem struct{}
 
 func (mbs MovePlayerSystem) Update(us ecs.UpdateState) {
 	values := ecs.GetSingleton[PlayerValues](us.World)
-	for _, e := range us.Entities {
+	input := ecs.GetSingleton[engine.Input](us.World)
+	for _, r := range ecs.Query2[Transform, Player](us) {
 		var dir rl.Vector2
-		transform := ecs.GetComponent[Transform](us.World, e)
-		player := ecs.GetComponent[Player](us.World, e)
+		transform, player := r.A, r.B
 
-		up := rl.IsKeyDown(rl.KeyUp)
-		down := rl.IsKeyDown(rl.KeyDown)
-		left := rl.IsKeyDown(rl.KeyLeft)
-		right := rl.IsKeyDown(rl.KeyRight)
+		up := input.IsKeyDown(rl.KeyUp)
+		down := input.IsKeyDown(rl.KeyDown)
+		left := input.IsKeyDown(rl.KeyLeft)
+		right := input.IsKeyDown(rl.KeyRight)
 		if (up || down) && !(up && down) {
 			if up {
 				dir.Y = -1
@@ -106,6 +112,7 @@ func (mbs MovePlayerSystem) Update(us ecs.UpdateState) {
 				dir.X = 1
 			}
 		}
+		transform.PrevPosition = transform.Position
 		dir = rl.Vector2Normalize(dir)
 		target := rl.Vector2Scale(dir, player.Speed)
 		player.Velocity = rl.Vector2MoveTowards(player.Velocity, target, values.Acceleration*us.DeltaTime)
@@ -121,10 +128,8 @@ type PlayerCollisionSystem struct{}
 
 func (PlayerCollisionSystem) Update(us ecs.UpdateState) {
 	w := ecs.GetSingleton[engine.Window](us.World)
-	for _, e := range us.Entities {
-		player := ecs.GetComponent[Player](us.World, e)
-		transform := ecs.GetComponent[Transform](us.World, e)
-		ball := ecs.GetComponent[PlayerGraphics](us.World, e)
+	for _, r := range ecs.Query3[Player, Transform, PlayerGraphics](us) {
+		player, transform, ball := r.A, r.B, r.C
 		if transform.Position.X <= ball.Radius || transform.Position.X >= w.Width-ball.Radius {
 			player.Velocity.X *= -0.4
 			transform.Position.X = rl.Clamp(transform.Position.X, ball.Radius, w.Width-ball.Radius)
@@ -137,8 +142,41 @@ func (PlayerCollisionSystem) Update(us ecs.UpdateState) {
 }
 
 func main() {
+	record := flag.String("record", "", "record the input to `file`")
+	replay := flag.String("replay", "", "replay the input from `file`")
+	flag.Parse()
+	if *record != "" && *replay != "" {
+		log.Fatal("-record and -replay can not be used together")
+	}
+
 	e := engine.New()
 	w := e.World
+	if *record != "" {
+		f, err := os.Create(*record)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		rec := engine.NewRecorder(e.Input, f)
+		defer func() {
+			if err := rec.Close(); err != nil {
+				log.Print(err)
+			}
+		}()
+		e.Input = rec
+	}
+	if *replay != "" {
+		f, err := os.Open(*replay)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		r, err := engine.NewReplayer(bufio.NewReader(f))
+		if err != nil {
+			log.Fatal(err)
+		}
+		e.Input = r
+	}
 
 	ecs.RegisterSingleton(w, &PlayerValues{
 		DashCooldown: 3,
@@ -150,27 +188,38 @@ func main() {
 	ecs.RegisterComponent[Transform](w)
 	ecs.RegisterComponent[PlayerGraphics](w)
 	ecs.RegisterComponent[Player](w)
-	ecs.RegisterSystem(w, PlayerDashSystem{}, ecs.Sig[Player](w))
-	ecs.RegisterSystem(w, MovePlayerSystem{}, ecs.Sig[Transform](w)|ecs.Sig[Player](w))
-	ecs.RegisterSystem(w, PlayerCollisionSystem{}, ecs.Sig[Transform](w)|ecs.Sig[Player](w)|ecs.Sig[PlayerGraphics](w))
-	ecs.RegisterSystem(w, DrawPlayerSystem{}, ecs.Sig[PlayerGraphics](w)|ecs.Sig[Transform](w)|ecs.Sig[Player](w))
+	// Dash reads key presses, so it runs once per frame before the fixed steps.
+	ecs.RegisterSystem(w, PlayerDashSystem{}, ecs.Sig[Player](w), ecs.InStage(ecs.PreUpdate))
+	ecs.RegisterSystem(w, MovePlayerSystem{}, ecs.Sig[Transform](w).Or(ecs.Sig[Player](w)),
+		ecs.InStage(ecs.FixedUpdate),
+	)
+	ecs.RegisterSystem(w, PlayerCollisionSystem{}, ecs.Sig[Transform](w).Or(ecs.Sig[Player](w), ecs.Sig[PlayerGraphics](w)),
+		ecs.InStage(ecs.FixedUpdate),
+		ecs.After[MovePlayerSystem](),
+	)
+	ecs.RegisterSystem(w, DrawPlayerSystem{}, ecs.Sig[PlayerGraphics](w).Or(ecs.Sig[Transform](w), ecs.Sig[Player](w)),
+		ecs.InStage(ecs.Render),
+	)
 	settings := ecs.GetSingleton[engine.WindowSettings](w)
 	settings.Width = 1280
 	settings.Height = 720
 	settings.Title = "Bouncy Balls"
 	settings.LogLevel = rl.LogError
 
-	player := ecs.NewEntity(w)
-	ecs.AddComponent(w, player, Player{})
-	ecs.AddComponent(w, player, Transform{
-		Position: rl.Vector2{X: 200, Y: 200},
-	})
-	ecs.AddComponent(w, player, PlayerGraphics{
-		Radius:          20,
-		Color:           rl.Green,
-		OnDashColor:     rl.Red,
-		OnCooldownColor: rl.DarkGreen,
-	})
+	playerPrefab := ecs.NewPrefab("player",
+		Player{},
+		Transform{
+			Position:     rl.Vector2{X: 200, Y: 200},
+			PrevPosition: rl.Vector2{X: 200, Y: 200},
+		},
+		PlayerGraphics{
+			Radius:          20,
+			Color:           rl.Green,
+			OnDashColor:     rl.Red,
+			OnCooldownColor: rl.DarkGreen,
+		},
+	)
+	ecs.Spawn(w, playerPrefab)
 	e.Run()
 }
 